@@ -2,9 +2,10 @@ package sealion
 
 import (
 	"encoding/binary"
+	"math/bits"
 )
 
-func cryptBlock(subkeys [40]uint32, dst, src []byte, decrypt bool) {
+func cryptBlock(subkeys *[40]uint32, dst, src []byte, decrypt bool) {
 	var t uint64
 	left := binary.BigEndian.Uint64(src[0:8])
 	right := binary.BigEndian.Uint64(src[8:16])
@@ -57,64 +58,49 @@ func cryptBlock(subkeys [40]uint32, dst, src []byte, decrypt bool) {
 }
 
 func feistelFunction(input uint64) uint64 {
-	G1 := gFunction(input)
+	// even holds G bytes 0, 2, 4, 6 and odd bytes 1, 3, 5, 7
+	even, odd := gLanes(input)
 
-	// Initial PHT without schedule
-	for x := 0; x < 4; x += 2 {
-		G1[x], G1[x+1] = pht8(&G1[x], &G1[x+1])
-		G1[x+4], G1[x+5] = pht8(&G1[x+4], &G1[x+5])
-	}
+	// Initial PHT without schedule pairs bytes (0, 1), (2, 3), (4, 5), (6, 7)
+	t, u := pht8Lanes(even, odd)
 
-	// PHT With Schedule
-	numberOfScheduledPHTLayers := 2
-	var intermediate [8]uint8
-	for j := 0; j < numberOfScheduledPHTLayers; j++ {
-		for x := 0; x < 4; x += 2 {
-			intermediate[x], intermediate[x+1] = pht8(&G1[x*2], &G1[(x+1)*2])
-			intermediate[x+4], intermediate[x+5] = pht8(&G1[1+(x*2)], &G1[1+((x+1)*2)])
-		}
-		copy(G1[:], intermediate[:])
-	}
+	// Two layers of PHT with schedule pair bytes (0, 2), (1, 3), (4, 6), (5, 7)
+	// and write them back as (0, 1), (4, 5), (2, 3), (6, 7). t and u hold the
+	// first and second byte of each pair, so the lanes are regrouped between
+	// layers rather than the bytes moved one at a time.
+	t, u = pht8Lanes(t&0x0000ffff0000ffff|u<<16&0xffff0000ffff0000, t>>16&0x0000ffff0000ffff|u&0xffff0000ffff0000)
+	t, u = pht8Lanes(t&0x00000000ffffffff|u<<32, t>>32|u&0xffffffff00000000)
 
-	return binary.BigEndian.Uint64(G1[:])
+	// Lane i of t and u now holds bytes 2i and 2i+1 of the output
+	return bits.ReverseBytes64(t | u<<8)
+}
+
+// gLanes computes the G function on a 64 bit input. Rotating every 16 bit
+// word of the input gives the S-box inputs s0, s2, s4 and s6; rotating the
+// whole word by a byte first gives s1, s3, s5 and s7. The S-box outputs are
+// returned in the low byte of each 16 bit lane, even ones first.
+func gLanes(input uint64) (even, odd uint64) {
+	e := rotate16LanesRightBy4(input)
+	o := rotate16LanesRightBy4(bits.RotateLeft64(e, 8))
+	even = uint64(sBoxes[0][uint8(e>>56)][uint8(e>>48)]) |
+		uint64(sBoxes[2][uint8(e>>40)][uint8(e>>32)])<<16 |
+		uint64(sBoxes[4][uint8(e>>24)][uint8(e>>16)])<<32 |
+		uint64(sBoxes[6][uint8(e>>8)][uint8(e)])<<48
+	odd = uint64(sBoxes[1][uint8(o>>56)][uint8(o>>48)]) |
+		uint64(sBoxes[3][uint8(o>>40)][uint8(o>>32)])<<16 |
+		uint64(sBoxes[5][uint8(o>>24)][uint8(o>>16)])<<32 |
+		uint64(sBoxes[7][uint8(o>>8)][uint8(o)])<<48
+	return even, odd
 }
 
 func gFunction(input uint64) [8]uint8 {
-	var s0, s1, s2, s3, s4, s5, s6, s7 uint16
-	// Split 64 bit input to four 16 bit blocks
-	// s0, s2, s4, s6 have the original input and s1, s3, s5, s7 have derived input
-	s0 = uint16(input >> (3 * 16))
-	s2 = uint16(input >> (2 * 16))
-	s4 = uint16(input >> (1 * 16))
-	s6 = uint16(input)
-	// Rotate the split blocks as per data p
-	rotate16RightBy4(&s0)
-	rotate16RightBy4(&s2)
-	rotate16RightBy4(&s4)
-	rotate16RightBy4(&s6)
-	// Do Expansion and then DDR with data p on four 16 bit blocks
-	// Expansion is performed by taking 1 byte each from corresponding s0, s2, s4, s6 and concatenating
-	// In case of s7, the second byte for concatenation is the first byte of s0
-	s1 = concatenate8ToGet16(shift16ToGet8(&s0, 2), shift16ToGet8(&s2, 1))
-	s3 = concatenate8ToGet16(shift16ToGet8(&s2, 2), shift16ToGet8(&s4, 1))
-	s5 = concatenate8ToGet16(shift16ToGet8(&s4, 2), shift16ToGet8(&s6, 1))
-	s7 = concatenate8ToGet16(shift16ToGet8(&s6, 2), shift16ToGet8(&s0, 1))
-	rotate16RightBy4(&s1)
-	rotate16RightBy4(&s3)
-	rotate16RightBy4(&s5)
-	rotate16RightBy4(&s7)
-	return [8]uint8{
-		// There are 8 16:8 APN S Boxes
-		// For the corresponding array implementation, we need to split 16 bits into 8 bits
-		sBoxes[0][shift16ToGet8(&s0, 1)][shift16ToGet8(&s0, 2)],
-		sBoxes[1][shift16ToGet8(&s1, 1)][shift16ToGet8(&s1, 2)],
-		sBoxes[2][shift16ToGet8(&s2, 1)][shift16ToGet8(&s2, 2)],
-		sBoxes[3][shift16ToGet8(&s3, 1)][shift16ToGet8(&s3, 2)],
-		sBoxes[4][shift16ToGet8(&s4, 1)][shift16ToGet8(&s4, 2)],
-		sBoxes[5][shift16ToGet8(&s5, 1)][shift16ToGet8(&s5, 2)],
-		sBoxes[6][shift16ToGet8(&s6, 1)][shift16ToGet8(&s6, 2)],
-		sBoxes[7][shift16ToGet8(&s7, 1)][shift16ToGet8(&s7, 2)],
+	even, odd := gLanes(input)
+	var g [8]uint8
+	for i := 0; i < 4; i++ {
+		g[2*i] = uint8(even >> (16 * i))
+		g[2*i+1] = uint8(odd >> (16 * i))
 	}
+	return g
 }
 
 func generateSubKeys(key []byte) [40]uint32 {
@@ -208,3 +194,24 @@ func generateSubKeys(key []byte) [40]uint32 {
 	}
 	return subkeys
 }
+
+// encryptBlocks4 encrypts four blocks at once, running their rounds side by
+// side so the CPU can overlap the work on independent blocks.
+func encryptBlocks4(subkeys *[40]uint32, dst, src []byte) {
+	var left, right [4]uint64
+	for j := range left {
+		left[j] = binary.BigEndian.Uint64(src[j*BlockSize:]) ^ concatenate32(&subkeys[0], &subkeys[1])
+		right[j] = binary.BigEndian.Uint64(src[j*BlockSize+8:]) ^ concatenate32(&subkeys[2], &subkeys[3])
+	}
+	for i := 0; i < 16; i++ {
+		k := concatenate32(&subkeys[4+(i*2)], &subkeys[5+(i*2)])
+		for j := range left {
+			left[j], right[j] = feistelFunction(left[j])^k^right[j], left[j]
+		}
+	}
+	for j := range left {
+		// Undo the last swap and whiten
+		binary.BigEndian.PutUint64(dst[j*BlockSize:], right[j]^concatenate32(&subkeys[36], &subkeys[37]))
+		binary.BigEndian.PutUint64(dst[j*BlockSize+8:], left[j]^concatenate32(&subkeys[38], &subkeys[39]))
+	}
+}
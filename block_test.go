@@ -0,0 +1,101 @@
+package sealion
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// Pinned from the byte-at-a-time implementation of the round function.
+var cipherTests = []struct {
+	key, plaintext, ciphertext string
+}{
+	{"000102030405060708090a0b0c0d0e0f", "00112233445566778899aabbccddeeff", "87abffb77ae7bbdfb353f331a570d77f"},
+	{"000102030405060708090a0b0c0d0e0f1011121314151617", "00112233445566778899aabbccddeeff", "58a261b2b73186925b96a8daecd5c666"},
+	{"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f", "00112233445566778899aabbccddeeff", "bd9ada4455a7ec4ed7beed55f387cb5c"},
+}
+
+func TestCipher(t *testing.T) {
+	for i, tt := range cipherTests {
+		b, err := NewCipher(fromHex(tt.key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := make([]byte, BlockSize)
+		b.Encrypt(out, fromHex(tt.plaintext))
+		if got := hex.EncodeToString(out); got != tt.ciphertext {
+			t.Errorf("#%d: Encrypt = %s, want %s", i, got, tt.ciphertext)
+		}
+		b.Decrypt(out, out)
+		if got := hex.EncodeToString(out); got != tt.plaintext {
+			t.Errorf("#%d: Decrypt = %s, want %s", i, got, tt.plaintext)
+		}
+	}
+}
+
+// TestCipherIterated feeds every ciphertext back in as the next plaintext, so
+// a wrong round function on any input reached along the way shows up.
+func TestCipherIterated(t *testing.T) {
+	for _, tt := range []struct {
+		keySize int
+		want    string
+	}{
+		{16, "2fb1dd5a6f3d5a34bb0150a7a80a83e6"},
+		{24, "aaf27fc922d58b8a14f63afbdbc5330f"},
+		{32, "0a331cade3bc693a55e8744177d5cfd6"},
+	} {
+		b, _ := NewCipher(make([]byte, tt.keySize))
+		x := make([]byte, BlockSize)
+		for i := 0; i < 10000; i++ {
+			b.Encrypt(x, x)
+		}
+		if got := hex.EncodeToString(x); got != tt.want {
+			t.Errorf("%d-byte key: got %s, want %s", tt.keySize, got, tt.want)
+		}
+		for i := 0; i < 10000; i++ {
+			b.Decrypt(x, x)
+		}
+		if !bytes.Equal(x, make([]byte, BlockSize)) {
+			t.Errorf("%d-byte key: decryption did not return to zero", tt.keySize)
+		}
+	}
+}
+
+func TestMod255Lanes(t *testing.T) {
+	const lanes = 0x0001000100010001
+	for a := 0; a < 256; a++ {
+		for b := 0; b < 256; b++ {
+			// Every lane holds the same sum, so a carry into a neighbour shows up
+			want := uint64(byte(a+b)%255) * lanes
+			if got := mod255Lanes(uint64(a)*lanes + uint64(b)*lanes); got != want {
+				t.Fatalf("(%d + %d) %% 255 = %#x, want %#x", a, b, got, want)
+			}
+		}
+	}
+}
+
+func TestMod65535(t *testing.T) {
+	for x := 0; x < 1<<16; x++ {
+		if got, want := mod65535(uint16(x)), uint16(x)%65535; got != want {
+			t.Fatalf("mod65535(%d) = %d, want %d", x, got, want)
+		}
+	}
+}
+
+func TestEncryptBlocks4(t *testing.T) {
+	c, _ := NewCipher(fromHex(cipherTests[2].key))
+	subkeys := &c.(*seaLionCipher).subkeys
+	src := make([]byte, 4*BlockSize)
+	for i := range src {
+		src[i] = byte(i * 7)
+	}
+	want := make([]byte, len(src))
+	for i := 0; i < len(src); i += BlockSize {
+		cryptBlock(subkeys, want[i:], src[i:], false)
+	}
+	got := make([]byte, len(src))
+	encryptBlocks4(subkeys, got, src)
+	if !bytes.Equal(got, want) {
+		t.Errorf("encryptBlocks4 = %x, want %x", got, want)
+	}
+}
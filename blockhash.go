@@ -0,0 +1,130 @@
+package sealion
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"hash"
+)
+
+const (
+	// Size of a Miyaguchi–Preneel digest in bytes
+	MiyaguchiPreneelSize = BlockSize
+	// Size of a Hirose double-block-length digest in bytes
+	HiroseSize = 2 * BlockSize
+)
+
+// hiroseConstant is the non-zero constant c separating the two Hirose
+// encryptions.
+var hiroseConstant = [BlockSize]byte{BlockSize - 1: 1}
+
+// blockHash is a Merkle–Damgård hash over 16-byte message blocks, padded with
+// a single one bit, zeros and the 64-bit message length in bits.
+type blockHash struct {
+	iv       []byte
+	state    []byte
+	compress func(state, block []byte)
+	buf      [BlockSize]byte
+	n        int
+	length   uint64
+}
+
+// NewMiyaguchiPreneel returns a hash.Hash computing a 128-bit digest with the
+// Miyaguchi–Preneel construction H_i = E_{H_{i-1}}(m_i) xor H_{i-1} xor m_i
+// over SEA-Lion with 128-bit keys.
+func NewMiyaguchiPreneel() hash.Hash {
+	iv := make([]byte, MiyaguchiPreneelSize)
+	for i := 0; i < 4; i++ {
+		binary.BigEndian.PutUint32(iv[i*4:], pi[i])
+	}
+	return newBlockHash(iv, compressMiyaguchiPreneel)
+}
+
+// NewHirose returns a hash.Hash computing a 256-bit digest with Hirose's
+// double-block-length construction over SEA-Lion with 256-bit keys:
+//
+//	G_i = E_{H_{i-1}||m_i}(G_{i-1}) xor G_{i-1}
+//	H_i = E_{H_{i-1}||m_i}(G_{i-1} xor c) xor G_{i-1} xor c
+func NewHirose() hash.Hash {
+	iv := make([]byte, HiroseSize)
+	for i := range pi {
+		binary.BigEndian.PutUint32(iv[i*4:], pi[i])
+	}
+	return newBlockHash(iv, compressHirose)
+}
+
+func newBlockHash(iv []byte, compress func(state, block []byte)) *blockHash {
+	h := &blockHash{iv: iv, state: make([]byte, len(iv)), compress: compress}
+	h.Reset()
+	return h
+}
+
+func (h *blockHash) Size() int {
+	return len(h.iv)
+}
+
+func (h *blockHash) BlockSize() int {
+	return BlockSize
+}
+
+func (h *blockHash) Reset() {
+	copy(h.state, h.iv)
+	h.n = 0
+	h.length = 0
+}
+
+func (h *blockHash) Write(p []byte) (int, error) {
+	written := len(p)
+	h.length += uint64(len(p))
+	for len(p) > 0 {
+		m := copy(h.buf[h.n:], p)
+		h.n += m
+		p = p[m:]
+		if h.n == BlockSize {
+			h.compress(h.state, h.buf[:])
+			h.n = 0
+		}
+	}
+	return written, nil
+}
+
+func (h *blockHash) Sum(in []byte) []byte {
+	// Pad a copy so the caller can keep writing
+	d := *h
+	d.state = append([]byte(nil), h.state...)
+
+	var pad [2 * BlockSize]byte
+	pad[0] = 0x80
+	padLen := BlockSize - d.n
+	if padLen < 9 {
+		padLen += BlockSize
+	}
+	binary.BigEndian.PutUint64(pad[padLen-8:], h.length*8)
+	d.Write(pad[:padLen])
+
+	return append(in, d.state...)
+}
+
+func compressMiyaguchiPreneel(state, block []byte) {
+	subkeys := generateSubKeys(state)
+	var e [BlockSize]byte
+	cryptBlock(&subkeys, e[:], block, false)
+	subtle.XORBytes(state, state, e[:])
+	subtle.XORBytes(state, state, block)
+}
+
+func compressHirose(state, block []byte) {
+	g, h := state[:BlockSize], state[BlockSize:]
+
+	var key [2 * BlockSize]byte
+	copy(key[:], h)
+	copy(key[BlockSize:], block)
+	subkeys := generateSubKeys(key[:])
+
+	var gc, eg, egc [BlockSize]byte
+	subtle.XORBytes(gc[:], g, hiroseConstant[:])
+	cryptBlock(&subkeys, eg[:], g, false)
+	cryptBlock(&subkeys, egc[:], gc[:], false)
+
+	subtle.XORBytes(g, eg[:], g)
+	subtle.XORBytes(h, egc[:], gc[:])
+}
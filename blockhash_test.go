@@ -0,0 +1,95 @@
+package sealion
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"hash"
+	"testing"
+)
+
+func testBlockHash(t *testing.T, newHash func() hash.Hash, size int) {
+	t.Helper()
+	h := newHash()
+	if h.Size() != size || h.BlockSize() != BlockSize {
+		t.Fatalf("Size %d, BlockSize %d", h.Size(), h.BlockSize())
+	}
+
+	msg := make([]byte, 100)
+	for i := range msg {
+		msg[i] = byte(i)
+	}
+
+	// Lengths around the padding boundaries all give distinct digests
+	seen := map[string]int{}
+	for n := 0; n <= 3*BlockSize+1; n++ {
+		h.Reset()
+		h.Write(msg[:n])
+		sum := h.Sum(nil)
+		if len(sum) != size {
+			t.Fatalf("length %d: digest of %d bytes", n, len(sum))
+		}
+		if m, ok := seen[string(sum)]; ok {
+			t.Errorf("lengths %d and %d collide", m, n)
+		}
+		seen[string(sum)] = n
+
+		// Writing in pieces gives the same digest
+		pieces := newHash()
+		for i := 0; i < n; i += 5 {
+			pieces.Write(msg[i:min(i+5, n)])
+		}
+		if got := pieces.Sum(nil); !bytes.Equal(got, sum) {
+			t.Errorf("length %d: piecewise digest differs", n)
+		}
+	}
+
+	h.Reset()
+	h.Write(msg[:20])
+	first := h.Sum([]byte("prefix"))
+	if !bytes.HasPrefix(first, []byte("prefix")) {
+		t.Error("Sum does not append to its argument")
+	}
+	h.Write(msg[20:40])
+	full := newHash()
+	full.Write(msg[:40])
+	if !bytes.Equal(h.Sum(nil), full.Sum(nil)) {
+		t.Error("Sum changed the state")
+	}
+}
+
+// emptyMessageBlock is the single padded block hashed for the empty message.
+var emptyMessageBlock = [BlockSize]byte{0x80}
+
+func TestMiyaguchiPreneel(t *testing.T) {
+	testBlockHash(t, NewMiyaguchiPreneel, MiyaguchiPreneelSize)
+
+	// H_1 = E_{H_0}(m) xor H_0 xor m
+	iv := NewMiyaguchiPreneel().(*blockHash).iv
+	b, _ := NewCipher(iv)
+	want := make([]byte, BlockSize)
+	b.Encrypt(want, emptyMessageBlock[:])
+	subtle.XORBytes(want, want, iv)
+	subtle.XORBytes(want, want, emptyMessageBlock[:])
+	if got := NewMiyaguchiPreneel().Sum(nil); !bytes.Equal(got, want) {
+		t.Errorf("empty message: got %x, want %x", got, want)
+	}
+}
+
+func TestHirose(t *testing.T) {
+	testBlockHash(t, NewHirose, HiroseSize)
+
+	// G_1 = E_{H_0||m}(G_0) xor G_0, H_1 = E_{H_0||m}(G_0 xor c) xor G_0 xor c
+	iv := NewHirose().(*blockHash).iv
+	g, h := iv[:BlockSize], iv[BlockSize:]
+	b, _ := NewCipher(append(append([]byte(nil), h...), emptyMessageBlock[:]...))
+	gc := make([]byte, BlockSize)
+	subtle.XORBytes(gc, g, hiroseConstant[:])
+	want := make([]byte, HiroseSize)
+	b.Encrypt(want[:BlockSize], g)
+	subtle.XORBytes(want[:BlockSize], want[:BlockSize], g)
+	b.Encrypt(want[BlockSize:], gc)
+	subtle.XORBytes(want[BlockSize:], want[BlockSize:], gc)
+	if got := NewHirose().Sum(nil); !bytes.Equal(got, want) {
+		t.Errorf("empty message: got %x, want %x", got, want)
+	}
+}
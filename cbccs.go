@@ -0,0 +1,153 @@
+package sealion
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+)
+
+// CBCCSVariant selects how CBC with ciphertext stealing orders the last two
+// ciphertext blocks, as defined in the addendum to NIST SP 800-38A.
+type CBCCSVariant int
+
+const (
+	// CBCCS1 keeps the partial penultimate block before the final block.
+	CBCCS1 CBCCSVariant = iota + 1
+	// CBCCS2 swaps the last two blocks only when the final block is partial.
+	CBCCS2
+	// CBCCS3 always swaps the last two blocks, as Kerberos does.
+	CBCCS3
+)
+
+// CBCCS encrypts and decrypts whole messages in CBC mode with ciphertext
+// stealing. Unlike a cipher.BlockMode, it accepts any message of at least one
+// block and every call processes exactly one message, so its output is the
+// same length as its input.
+type CBCCS struct {
+	b       cipher.Block
+	variant CBCCSVariant
+}
+
+// NewCBCCS returns CBC with ciphertext stealing over the given 128-bit block
+// cipher, laying out the last two blocks according to variant.
+func NewCBCCS(b cipher.Block, variant CBCCSVariant) *CBCCS {
+	if b.BlockSize() != BlockSize {
+		panic("sealion: CBC-CS requires a 128-bit block cipher")
+	}
+	if variant < CBCCS1 || variant > CBCCS3 {
+		panic("sealion: invalid CBC-CS variant")
+	}
+	return &CBCCS{b: b, variant: variant}
+}
+
+// Encrypt encrypts the message src with iv into dst. src must be at least one
+// block long; dst and src may overlap entirely or not at all.
+func (c *CBCCS) Encrypt(dst, src, iv []byte) {
+	dst = c.check(dst, src, iv)
+	c.encryptMessage(dst, src, iv)
+}
+
+// Decrypt decrypts the message src, encrypted with iv, into dst. src must be
+// at least one block long; dst and src may overlap entirely or not at all.
+func (c *CBCCS) Decrypt(dst, src, iv []byte) {
+	dst = c.check(dst, src, iv)
+	c.decryptMessage(dst, src, iv)
+}
+
+func (c *CBCCS) check(dst, src, iv []byte) []byte {
+	if len(iv) != BlockSize {
+		panic("sealion: IV length must equal block size")
+	}
+	if len(src) < BlockSize {
+		panic("sealion: input not full block")
+	}
+	if len(dst) < len(src) {
+		panic("sealion: output smaller than input")
+	}
+	dst = dst[:len(src)]
+	if inexactOverlap(dst, src) {
+		panic("sealion: invalid buffer overlap")
+	}
+	return dst
+}
+
+// swapped reports whether the last two blocks of a message whose final block
+// holds d bytes are stored in reverse order.
+func (c *CBCCS) swapped(d int) bool {
+	return c.variant == CBCCS3 || (c.variant == CBCCS2 && d != BlockSize)
+}
+
+func (c *CBCCS) encryptMessage(dst, src, iv []byte) {
+	// n blocks, the last of which holds d bytes
+	n := (len(src) + BlockSize - 1) / BlockSize
+	d := len(src) - (n-1)*BlockSize
+
+	var chain [BlockSize]byte
+	copy(chain[:], iv)
+	for i := 0; i < n-1; i++ {
+		subtle.XORBytes(chain[:], chain[:], src[i*BlockSize:(i+1)*BlockSize])
+		c.b.Encrypt(chain[:], chain[:])
+		copy(dst[i*BlockSize:], chain[:])
+	}
+	if n == 1 {
+		subtle.XORBytes(chain[:], chain[:], src)
+		c.b.Encrypt(dst, chain[:])
+		return
+	}
+
+	// C_n = E(C_{n-1} xor (P_n* || 0)), C_{n-1}* = MSB_d(C_{n-1})
+	penultimate := chain
+	subtle.XORBytes(chain[:], chain[:], src[(n-1)*BlockSize:])
+	c.b.Encrypt(chain[:], chain[:])
+
+	tail := dst[(n-2)*BlockSize:]
+	if c.swapped(d) {
+		copy(tail, chain[:])
+		copy(tail[BlockSize:], penultimate[:d])
+	} else {
+		copy(tail, penultimate[:d])
+		copy(tail[d:], chain[:])
+	}
+}
+
+func (c *CBCCS) decryptMessage(dst, src, iv []byte) {
+	n := (len(src) + BlockSize - 1) / BlockSize
+	d := len(src) - (n-1)*BlockSize
+
+	var chain [BlockSize]byte
+	copy(chain[:], iv)
+	if n == 1 {
+		c.b.Decrypt(dst, src)
+		subtle.XORBytes(dst, dst, chain[:])
+		return
+	}
+
+	// Recover C_{n-1}* and C_n in their unswapped roles
+	var partial, last [BlockSize]byte
+	tail := src[(n-2)*BlockSize:]
+	if c.swapped(d) {
+		copy(last[:], tail[:BlockSize])
+		copy(partial[:], tail[BlockSize:])
+	} else {
+		copy(partial[:], tail[:d])
+		copy(last[:], tail[d:])
+	}
+
+	// Z = D(C_n) = C_{n-1} xor (P_n* || 0), so the tail of Z completes C_{n-1}
+	var z, penultimate [BlockSize]byte
+	c.b.Decrypt(z[:], last[:])
+	copy(penultimate[:], partial[:d])
+	copy(penultimate[d:], z[d:])
+
+	var block, prev [BlockSize]byte
+	for i := 0; i < n-2; i++ {
+		copy(prev[:], src[i*BlockSize:])
+		c.b.Decrypt(block[:], prev[:])
+		subtle.XORBytes(dst[i*BlockSize:], block[:], chain[:])
+		chain = prev
+	}
+
+	// P_n* = MSB_d(Z xor C_{n-1}), P_{n-1} = D(C_{n-1}) xor C_{n-2}
+	subtle.XORBytes(dst[(n-1)*BlockSize:], z[:d], penultimate[:d])
+	c.b.Decrypt(block[:], penultimate[:])
+	subtle.XORBytes(dst[(n-2)*BlockSize:], block[:], chain[:])
+}
@@ -0,0 +1,86 @@
+package sealion
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"testing"
+)
+
+// RFC 3962, Appendix B (CBC-CS3 as used by Kerberos), with AES in place of
+// SEA-Lion and a zero IV
+var cbccsAESTests = []struct {
+	plaintext, ciphertext string
+}{
+	{"4920776f756c64206c696b652074686520", "c6353568f2bf8cb4d8a580362da7ff7f97"},
+	{"4920776f756c64206c696b65207468652047656e6572616c20476175277320", "fc00783e0efdb2c1d445d4c8eff7ed2297687268d6ecccc0c07b25e25ecfe5"},
+	{"4920776f756c64206c696b65207468652047656e6572616c2047617527732043", "39312523a78662d5be7fcbcc98ebf5a897687268d6ecccc0c07b25e25ecfe584"},
+}
+
+func TestCBCCSAES(t *testing.T) {
+	b, _ := aes.NewCipher(fromHex("636869636b656e207465726979616b69"))
+	c := NewCBCCS(b, CBCCS3)
+	iv := make([]byte, BlockSize)
+	for i, tt := range cbccsAESTests {
+		plaintext, want := fromHex(tt.plaintext), fromHex(tt.ciphertext)
+		ct := make([]byte, len(plaintext))
+		c.Encrypt(ct, plaintext, iv)
+		if !bytes.Equal(ct, want) {
+			t.Errorf("#%d: got %x, want %x", i, ct, want)
+			continue
+		}
+		c.Decrypt(ct, ct, iv)
+		if !bytes.Equal(ct, plaintext) {
+			t.Errorf("#%d: decrypted %x", i, ct)
+		}
+	}
+}
+
+func TestCBCCS(t *testing.T) {
+	b, _ := NewCipher(make([]byte, 16))
+	iv := []byte("0123456789abcdef")
+	modes := map[CBCCSVariant]*CBCCS{}
+	for _, v := range []CBCCSVariant{CBCCS1, CBCCS2, CBCCS3} {
+		modes[v] = NewCBCCS(b, v)
+	}
+
+	for n := BlockSize; n <= 5*BlockSize; n++ {
+		plaintext := make([]byte, n)
+		for i := range plaintext {
+			plaintext[i] = byte(i)
+		}
+		out := map[CBCCSVariant][]byte{}
+		for v, c := range modes {
+			ct := make([]byte, n)
+			c.Encrypt(ct, plaintext, iv)
+			out[v] = ct
+
+			pt := append([]byte(nil), ct...)
+			c.Decrypt(pt, pt, iv)
+			if !bytes.Equal(pt, plaintext) {
+				t.Fatalf("variant %d, length %d: round trip failed", v, n)
+			}
+		}
+
+		// CS2 matches CS1 when the last block is full and CS3 otherwise
+		if n%BlockSize == 0 {
+			cbc := make([]byte, n)
+			cipher.NewCBCEncrypter(b, iv).CryptBlocks(cbc, plaintext)
+			if !bytes.Equal(out[CBCCS1], cbc) || !bytes.Equal(out[CBCCS2], cbc) {
+				t.Errorf("length %d: CS1 and CS2 differ from plain CBC", n)
+			}
+		} else if !bytes.Equal(out[CBCCS2], out[CBCCS3]) {
+			t.Errorf("length %d: CS2 differs from CS3", n)
+		}
+	}
+}
+
+func TestCBCCSShortInput(t *testing.T) {
+	b, _ := NewCipher(make([]byte, 16))
+	defer func() {
+		if recover() == nil {
+			t.Error("encrypted an input shorter than a block")
+		}
+	}()
+	NewCBCCS(b, CBCCS3).Encrypt(make([]byte, 15), make([]byte, 15), make([]byte, BlockSize))
+}
@@ -0,0 +1,166 @@
+package sealion
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+type ccm struct {
+	b          cipher.Block
+	tagSize    int // M
+	lengthSize int // L
+	maxLength  uint64
+}
+
+// NewCCM returns the CCM mode (RFC 3610, SP 800-38C) of the given 128-bit
+// block cipher. tagSize is the tag length M in bytes (an even number from 4
+// to 16) and lengthSize is the size L of the message length field in bytes
+// (2 to 8), which bounds the plaintext to 2^(8L)-1 bytes. The nonce is
+// 15-L bytes long.
+func NewCCM(b cipher.Block, tagSize, lengthSize int) (cipher.AEAD, error) {
+	if b.BlockSize() != BlockSize {
+		return nil, errors.New("sealion: CCM requires a 128-bit block cipher")
+	}
+	if tagSize < 4 || tagSize > 16 || tagSize%2 != 0 {
+		return nil, errors.New("sealion: invalid CCM tag size")
+	}
+	if lengthSize < 2 || lengthSize > 8 {
+		return nil, errors.New("sealion: invalid CCM length field size")
+	}
+
+	c := &ccm{b: b, tagSize: tagSize, lengthSize: lengthSize}
+	c.maxLength = math.MaxUint64
+	if lengthSize < 8 {
+		c.maxLength = 1<<(8*uint(lengthSize)) - 1
+	}
+	return c, nil
+}
+
+func (c *ccm) NonceSize() int {
+	return 15 - c.lengthSize
+}
+
+func (c *ccm) Overhead() int {
+	return c.tagSize
+}
+
+func (c *ccm) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != c.NonceSize() {
+		panic("sealion: incorrect nonce length given to CCM")
+	}
+	if uint64(len(plaintext)) > c.maxLength {
+		panic("sealion: message too large for CCM")
+	}
+
+	ret, out := sliceForAppend(dst, len(plaintext)+c.tagSize)
+	if inexactOverlap(out, plaintext) {
+		panic("sealion: invalid buffer overlap")
+	}
+
+	tag := c.mac(nonce, plaintext, additionalData)
+	c.ctr(nonce).XORKeyStream(out, plaintext)
+	copy(out[len(plaintext):], tag[:c.tagSize])
+	return ret
+}
+
+func (c *ccm) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != c.NonceSize() {
+		panic("sealion: incorrect nonce length given to CCM")
+	}
+	if len(ciphertext) < c.tagSize || uint64(len(ciphertext)-c.tagSize) > c.maxLength {
+		return nil, errOpen
+	}
+
+	tag := ciphertext[len(ciphertext)-c.tagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-c.tagSize]
+
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	if inexactOverlap(out, ciphertext) {
+		panic("sealion: invalid buffer overlap")
+	}
+
+	c.ctr(nonce).XORKeyStream(out, ciphertext)
+
+	expected := c.mac(nonce, out, additionalData)
+	if subtle.ConstantTimeCompare(expected[:c.tagSize], tag) != 1 {
+		for i := range out {
+			out[i] = 0
+		}
+		return nil, errOpen
+	}
+	return ret, nil
+}
+
+// counterBlock returns A_i = flags || nonce || [i]_L.
+func (c *ccm) counterBlock(nonce []byte, i uint64) [BlockSize]byte {
+	var a [BlockSize]byte
+	a[0] = byte(c.lengthSize - 1)
+	copy(a[1:], nonce)
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], i)
+	copy(a[BlockSize-c.lengthSize:], counter[8-c.lengthSize:])
+	return a
+}
+
+// ctr returns the keystream for the message, starting at A_1.
+func (c *ccm) ctr(nonce []byte) cipher.Stream {
+	a := c.counterBlock(nonce, 1)
+	return cipher.NewCTR(c.b, a[:])
+}
+
+// mac computes the CBC-MAC T over B_0, the encoded associated data and the
+// plaintext, and returns it encrypted with S_0.
+func (c *ccm) mac(nonce, plaintext, additionalData []byte) [BlockSize]byte {
+	// B_0 = flags || nonce || [l(m)]_L
+	x := c.counterBlock(nonce, uint64(len(plaintext)))
+	x[0] = byte(8*((c.tagSize-2)/2) + (c.lengthSize - 1))
+	if len(additionalData) > 0 {
+		x[0] |= 0x40
+	}
+	c.b.Encrypt(x[:], x[:])
+
+	if len(additionalData) > 0 {
+		// Prefix the associated data with its encoded length
+		var header []byte
+		switch n := uint64(len(additionalData)); {
+		case n < 1<<16-1<<8:
+			header = binary.BigEndian.AppendUint16(nil, uint16(n))
+		case n <= math.MaxUint32:
+			header = binary.BigEndian.AppendUint32([]byte{0xff, 0xfe}, uint32(n))
+		default:
+			header = binary.BigEndian.AppendUint64([]byte{0xff, 0xff}, n)
+		}
+		c.cbcMAC(&x, header, additionalData)
+	}
+	c.cbcMAC(&x, nil, plaintext)
+
+	s0 := c.counterBlock(nonce, 0)
+	c.b.Encrypt(s0[:], s0[:])
+	subtle.XORBytes(x[:], x[:], s0[:])
+	return x
+}
+
+// cbcMAC absorbs prefix || msg, zero padded to a whole number of blocks, into
+// the CBC-MAC state x.
+func (c *ccm) cbcMAC(x *[BlockSize]byte, prefix, msg []byte) {
+	var block [BlockSize]byte
+	used := copy(block[:], prefix)
+	for {
+		m := copy(block[used:], msg)
+		msg = msg[m:]
+		used += m
+		if used == 0 {
+			return
+		}
+		subtle.XORBytes(x[:], x[:], block[:])
+		c.b.Encrypt(x[:], x[:])
+		if len(msg) == 0 {
+			return
+		}
+		block = [BlockSize]byte{}
+		used = 0
+	}
+}
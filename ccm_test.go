@@ -0,0 +1,53 @@
+package sealion
+
+import (
+	"crypto/aes"
+	"testing"
+)
+
+// RFC 3610, packet vectors 1 and 4, with AES in place of SEA-Lion
+var ccmAESTests = []struct {
+	nonce, ad, plaintext, ciphertext string
+}{
+	{"00000003020100A0A1A2A3A4A5", "0001020304050607", "08090A0B0C0D0E0F101112131415161718191A1B1C1D1E", "588C979A61C663D2F066D0C2C0F989806D5F6B61DAC38417E8D12CFDF926E0"},
+	{"00000006050403A0A1A2A3A4A5", "000102030405060708090A0B", "0C0D0E0F101112131415161718191A1B1C1D1E", "A28C6865939A9A79FAAA5C4C2A9D4A91CDAC8C96C861B9C9E61EF1"},
+}
+
+func TestCCMAES(t *testing.T) {
+	b, _ := aes.NewCipher(fromHex("C0C1C2C3C4C5C6C7C8C9CACBCCCDCECF"))
+	ccm, err := NewCCM(b, 8, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tt := range ccmAESTests {
+		checkAEAD(t, i, ccm, tt.nonce, tt.ad, tt.plaintext, tt.ciphertext)
+	}
+}
+
+func TestCCM(t *testing.T) {
+	b, _ := NewCipher(make([]byte, 16))
+	for _, params := range [][2]int{{4, 8}, {8, 2}, {16, 3}} {
+		ccm, err := NewCCM(b, params[0], params[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		testAEAD(t, ccm)
+	}
+
+	for _, params := range [][2]int{{5, 2}, {18, 2}, {16, 1}, {16, 9}} {
+		if _, err := NewCCM(b, params[0], params[1]); err == nil {
+			t.Errorf("accepted tag size %d and length size %d", params[0], params[1])
+		}
+	}
+}
+
+func TestCCMMessageTooLarge(t *testing.T) {
+	b, _ := NewCipher(make([]byte, 16))
+	ccm, _ := NewCCM(b, 16, 2)
+	defer func() {
+		if recover() == nil {
+			t.Error("sealed a message longer than the length field allows")
+		}
+	}()
+	ccm.Seal(nil, make([]byte, ccm.NonceSize()), make([]byte, 1<<16), nil)
+}
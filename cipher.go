@@ -2,6 +2,7 @@ package sealion
 
 import (
 	"crypto/cipher"
+	"errors"
 	"strconv"
 )
 
@@ -17,6 +18,8 @@ func (k KeySizeError) Error() string {
 	return "sealion: invalid key size " + strconv.Itoa(int(k))
 }
 
+var errOpen = errors.New("sealion: message authentication failed")
+
 func NewCipher(key []byte) (cipher.Block, error) {
 
 	switch len(key) {
@@ -32,20 +35,20 @@ func NewCipher(key []byte) (cipher.Block, error) {
 	return c, nil
 }
 
-func (s seaLionCipher) BlockSize() int {
+func (s *seaLionCipher) BlockSize() int {
 	return BlockSize
 }
 
-func (s seaLionCipher) Encrypt(dst, src []byte) {
+func (s *seaLionCipher) Encrypt(dst, src []byte) {
 	if len(src) < BlockSize {
 		panic("sealion: input not full block")
 	}
-	cryptBlock(s.subkeys, dst, src, false)
+	cryptBlock(&s.subkeys, dst, src, false)
 }
 
-func (s seaLionCipher) Decrypt(dst, src []byte) {
+func (s *seaLionCipher) Decrypt(dst, src []byte) {
 	if len(src) < BlockSize {
 		panic("sealion: input not full block")
 	}
-	cryptBlock(s.subkeys, dst, src, true)
+	cryptBlock(&s.subkeys, dst, src, true)
 }
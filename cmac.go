@@ -0,0 +1,110 @@
+package sealion
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"hash"
+)
+
+// cmac implements CMAC (RFC 4493, SP 800-38B) as a streaming hash.Hash.
+type cmac struct {
+	b      cipher.Block
+	k1, k2 [BlockSize]byte
+	x      [BlockSize]byte // CBC chaining value
+	buf    [BlockSize]byte // pending block, only processed once more data follows
+	n      int
+}
+
+// NewCMAC returns a hash.Hash computing the CMAC of the data written to it
+// under a SEA-Lion key. Sum appends a 16-byte tag.
+func NewCMAC(key []byte) (hash.Hash, error) {
+	b, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return newCMAC(b), nil
+}
+
+func newCMAC(b cipher.Block) *cmac {
+	c := &cmac{b: b}
+	c.k1, c.k2 = cmacSubkeys(b)
+	return c
+}
+
+func (c *cmac) Size() int {
+	return BlockSize
+}
+
+func (c *cmac) BlockSize() int {
+	return BlockSize
+}
+
+func (c *cmac) Reset() {
+	c.x = [BlockSize]byte{}
+	c.buf = [BlockSize]byte{}
+	c.n = 0
+}
+
+func (c *cmac) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(p) > 0 {
+		// The final block needs subkey processing, so a full buffer is only
+		// chained in once we know it is not the last one
+		if c.n == BlockSize {
+			subtle.XORBytes(c.x[:], c.x[:], c.buf[:])
+			c.b.Encrypt(c.x[:], c.x[:])
+			c.n = 0
+		}
+		m := copy(c.buf[c.n:], p)
+		c.n += m
+		p = p[m:]
+	}
+	return written, nil
+}
+
+func (c *cmac) Sum(in []byte) []byte {
+	x := c.x
+	if c.n == BlockSize {
+		subtle.XORBytes(x[:], x[:], c.k1[:])
+	} else {
+		subtle.XORBytes(x[:], x[:], c.k2[:])
+		x[c.n] ^= 0x80
+	}
+	subtle.XORBytes(x[:], x[:], c.buf[:c.n])
+	c.b.Encrypt(x[:], x[:])
+	return append(in, x[:]...)
+}
+
+// cmacSubkeys derives the CMAC subkeys K1 and K2 of b.
+func cmacSubkeys(b cipher.Block) (k1, k2 [BlockSize]byte) {
+	b.Encrypt(k1[:], k1[:])
+	dbl(&k1)
+	k2 = k1
+	dbl(&k2)
+	return
+}
+
+// cmacSum returns the CMAC of msg under b with precomputed subkeys k1 and k2.
+func cmacSum(b cipher.Block, k1, k2 *[BlockSize]byte, msg []byte) [BlockSize]byte {
+	return cmacChain(b, k1, k2, [BlockSize]byte{}, msg)
+}
+
+// cmacChain continues a CMAC computation from the chaining value x over the
+// rest of the message. msg must only be empty if no block preceded it.
+func cmacChain(b cipher.Block, k1, k2 *[BlockSize]byte, x [BlockSize]byte, msg []byte) [BlockSize]byte {
+	for len(msg) > BlockSize {
+		subtle.XORBytes(x[:], x[:], msg[:BlockSize])
+		b.Encrypt(x[:], x[:])
+		msg = msg[BlockSize:]
+	}
+
+	if len(msg) == BlockSize {
+		subtle.XORBytes(x[:], x[:], k1[:])
+	} else {
+		subtle.XORBytes(x[:], x[:], k2[:])
+		x[len(msg)] ^= 0x80
+	}
+	subtle.XORBytes(x[:], x[:], msg)
+	b.Encrypt(x[:], x[:])
+	return x
+}
@@ -0,0 +1,74 @@
+package sealion
+
+import (
+	"bytes"
+	"crypto/aes"
+	"testing"
+)
+
+// RFC 4493 section 4, with AES in place of SEA-Lion
+var cmacAESTests = []struct {
+	length int
+	tag    string
+}{
+	{0, "bb1d6929e95937287fa37d129b756746"},
+	{16, "070a16b46b4d4144f79bdd9dd04a287c"},
+	{40, "dfa66747de9ae63030ca32611497c827"},
+	{64, "51f0bebf7e3b9d92fc49741779363cfe"},
+}
+
+const cmacAESMessage = "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710"
+
+func TestCMACAES(t *testing.T) {
+	b, _ := aes.NewCipher(fromHex("2b7e151628aed2a6abf7158809cf4f3c"))
+	msg := fromHex(cmacAESMessage)
+	for i, tt := range cmacAESTests {
+		want := fromHex(tt.tag)
+
+		// Write in pieces that do not line up with the block size
+		mac := newCMAC(b)
+		for m := msg[:tt.length]; len(m) > 0; {
+			n := min(7, len(m))
+			mac.Write(m[:n])
+			m = m[n:]
+		}
+		if got := mac.Sum(nil); !bytes.Equal(got, want) {
+			t.Errorf("#%d: got %x, want %x", i, got, want)
+		}
+
+		mac.Reset()
+		mac.Write(msg[:tt.length])
+		if got := mac.Sum(nil); !bytes.Equal(got, want) {
+			t.Errorf("#%d: after Reset got %x, want %x", i, got, want)
+		}
+
+		k1, k2 := cmacSubkeys(b)
+		if got := cmacSum(b, &k1, &k2, msg[:tt.length]); !bytes.Equal(got[:], want) {
+			t.Errorf("#%d: cmacSum got %x, want %x", i, got, want)
+		}
+	}
+}
+
+func TestCMAC(t *testing.T) {
+	mac, err := NewCMAC(make([]byte, 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mac.Size() != BlockSize || mac.BlockSize() != BlockSize {
+		t.Errorf("Size %d, BlockSize %d", mac.Size(), mac.BlockSize())
+	}
+
+	mac.Write([]byte("hello"))
+	first := mac.Sum(nil)
+	if again := mac.Sum(nil); !bytes.Equal(first, again) {
+		t.Error("Sum changed the state")
+	}
+	mac.Write([]byte(" world"))
+	if bytes.Equal(mac.Sum(nil), first) {
+		t.Error("Write after Sum had no effect")
+	}
+
+	if _, err := NewCMAC(make([]byte, 15)); err == nil {
+		t.Error("accepted a 15-byte key")
+	}
+}
@@ -0,0 +1,131 @@
+package sealion
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+)
+
+const (
+	// Format identifier prefixed to every committing ciphertext
+	committingFormatV1 = 0x01
+	committingSize     = 2 * BlockSize
+)
+
+// Derivation labels of the first commitment and encryption key blocks: the
+// commitment uses labels 0-1 and the key labels 2-3
+const (
+	committingLabelCommitment = 0
+	committingLabelKey        = 2
+)
+
+type committing struct {
+	b       cipher.Block
+	k1, k2  [BlockSize]byte
+	keySize int
+	newAEAD func(cipher.Block) (cipher.AEAD, error)
+
+	nonceSize, overhead int
+}
+
+// NewCommittingAEAD returns a key-committing AEAD: a ciphertext can only be
+// opened under the key that sealed it, which defeats partitioning oracle and
+// "invisible salamander" attacks on multi-key deployments.
+//
+// For every message, a 256-bit commitment and a fresh data key are derived
+// from key and the nonce with SEA-Lion CMAC. The data key seals the message
+// with the AEAD returned by newAEAD (for example cipher.NewGCM, NewOCB or
+// NewEAX) and the output is a format identifier byte, the commitment and the
+// inner ciphertext. Open checks the commitment before decrypting.
+func NewCommittingAEAD(key []byte, newAEAD func(cipher.Block) (cipher.AEAD, error)) (cipher.AEAD, error) {
+	b, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Probe the inner mode for its nonce size and overhead
+	inner, err := newAEAD(b)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &committing{
+		b:         b,
+		keySize:   len(key),
+		newAEAD:   newAEAD,
+		nonceSize: inner.NonceSize(),
+		overhead:  1 + committingSize + inner.Overhead(),
+	}
+	c.k1, c.k2 = cmacSubkeys(b)
+	return c, nil
+}
+
+func (c *committing) NonceSize() int {
+	return c.nonceSize
+}
+
+func (c *committing) Overhead() int {
+	return c.overhead
+}
+
+func (c *committing) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	commitment, inner := c.derive(nonce)
+	ret, out := sliceForAppend(dst, 1+committingSize)
+	if anyOverlap(out, plaintext) {
+		plaintext = append([]byte(nil), plaintext...)
+	}
+	out[0] = committingFormatV1
+	copy(out[1:], commitment[:])
+	return inner.Seal(ret, nonce, plaintext, additionalData)
+}
+
+func (c *committing) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < c.overhead || ciphertext[0] != committingFormatV1 {
+		return nil, errOpen
+	}
+
+	commitment, inner := c.derive(nonce)
+	if subtle.ConstantTimeCompare(commitment[:], ciphertext[1:1+committingSize]) != 1 {
+		return nil, errOpen
+	}
+	sealed := ciphertext[1+committingSize:]
+	if _, out := sliceForAppend(dst, len(sealed)); anyOverlap(out, ciphertext) {
+		// Decrypting in place: move the inner ciphertext to where the
+		// plaintext goes, as the inner AEAD only allows an exact overlap
+		copy(out, sealed)
+		sealed = out
+	}
+	return inner.Open(dst, nonce, sealed, additionalData)
+}
+
+// derive returns the commitment and the inner AEAD under the data key for
+// nonce. Block i of the derivation is CMAC_K([i] || nonce).
+func (c *committing) derive(nonce []byte) ([committingSize]byte, cipher.AEAD) {
+	msg := make([]byte, 1+len(nonce))
+	copy(msg[1:], nonce)
+	block := func(label int) [BlockSize]byte {
+		msg[0] = byte(label)
+		return cmacSum(c.b, &c.k1, &c.k2, msg)
+	}
+
+	var commitment [committingSize]byte
+	for i := 0; i < committingSize/BlockSize; i++ {
+		b := block(committingLabelCommitment + i)
+		copy(commitment[i*BlockSize:], b[:])
+	}
+
+	key := make([]byte, 0, 2*BlockSize)
+	for i := 0; len(key) < c.keySize; i++ {
+		b := block(committingLabelKey + i)
+		key = append(key, b[:]...)
+	}
+
+	b, err := NewCipher(key[:c.keySize])
+	if err != nil {
+		panic(err)
+	}
+	inner, err := c.newAEAD(b)
+	if err != nil {
+		panic(err)
+	}
+	return commitment, inner
+}
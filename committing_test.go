@@ -0,0 +1,53 @@
+package sealion
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+)
+
+func TestCommittingAEAD(t *testing.T) {
+	for _, newAEAD := range []func(cipher.Block) (cipher.AEAD, error){cipher.NewGCM, NewOCB, NewEAX} {
+		c, err := NewCommittingAEAD(make([]byte, 32), newAEAD)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testAEAD(t, c)
+	}
+}
+
+func TestCommittingAEADCommitment(t *testing.T) {
+	key := []byte("committing key, sixteen+16 bytes")
+	c, err := NewCommittingAEAD(key, NewOCB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, c.NonceSize())
+	ct := c.Seal(nil, nonce, []byte("message"), nil)
+
+	if ct[0] != committingFormatV1 {
+		t.Errorf("format byte %#x", ct[0])
+	}
+
+	// The commitment is CMAC_K(0 || nonce) || CMAC_K(1 || nonce)
+	mac, _ := NewCMAC(key)
+	var want []byte
+	for i := byte(0); i < 2; i++ {
+		mac.Reset()
+		mac.Write(append([]byte{i}, nonce...))
+		want = mac.Sum(want)
+	}
+	if got := ct[1 : 1+committingSize]; !bytes.Equal(got, want) {
+		t.Errorf("commitment %x, want %x", got, want)
+	}
+
+	other, _ := NewCommittingAEAD(bytes.ToUpper(key), NewOCB)
+	if _, err := other.Open(nil, nonce, ct, nil); err == nil {
+		t.Error("opened under a different key")
+	}
+
+	ct[0] = 0x02
+	if _, err := c.Open(nil, nonce, ct, nil); err == nil {
+		t.Error("opened with an unknown format byte")
+	}
+}
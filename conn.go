@@ -0,0 +1,414 @@
+package sealion
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	connVersion   = 0x01
+	connNonceSize = 32
+	connHelloLen  = 1 + connNonceSize
+	// Maximum plaintext bytes carried by one record
+	connMaxPlaintext = 16 * 1024
+	// Records sealed under one key before the sender switches to the next
+	connRekeyInterval = 1 << 24
+	// How long Close waits for the peer to take the close record
+	connCloseTimeout = 5 * time.Second
+)
+
+// Record types, carried in the first plaintext byte of every record
+const (
+	connRecordData = iota
+	connRecordKeyUpdate
+	connRecordClose
+)
+
+var (
+	connLabelClientWrite    = []byte("sealion conn client write")
+	connLabelServerWrite    = []byte("sealion conn server write")
+	connLabelClientFinished = []byte("sealion conn client finished")
+	connLabelServerFinished = []byte("sealion conn server finished")
+	connLabelKeyUpdate      = []byte("sealion conn key update")
+
+	errConnHandshake = errors.New("sealion: handshake failed")
+	errConnRecord    = errors.New("sealion: invalid or corrupted record")
+	errConnClosed    = errors.New("sealion: use of closed connection")
+)
+
+// Conn is a net.Conn secured with a pre-shared SEA-Lion key. The handshake
+// exchanges random nonces, derives per-direction keys from the key and both
+// nonces with DeriveKey and confirms them with CMAC, so a peer holding a
+// different key is rejected before any data flows. Records are sealed with
+// OCB, with the record sequence number as nonce, and each direction moves to
+// a fresh key every 2^24 records.
+//
+// The handshake runs on the first Read or Write, or explicitly through
+// Handshake.
+type Conn struct {
+	conn     net.Conn
+	psk      []byte
+	isClient bool
+
+	handshakeMu  sync.Mutex
+	handshakeErr error
+	// handshaked is read without handshakeMu, which is held across blocking
+	// I/O during the handshake
+	handshaked atomic.Bool
+
+	in, out halfConn
+
+	input []byte // plaintext received but not yet read
+}
+
+// halfConn is the record state of one direction.
+type halfConn struct {
+	sync.Mutex
+	key   []byte
+	aead  cipher.AEAD
+	seq   uint64
+	nonce []byte
+	buf   []byte
+	err   error
+}
+
+// NewClientConn returns the client side of a secure channel over conn, keyed
+// with the 16, 24 or 32 byte pre-shared key psk.
+func NewClientConn(conn net.Conn, psk []byte) (*Conn, error) {
+	return newConn(conn, psk, true)
+}
+
+// NewServerConn returns the server side of a secure channel over conn, keyed
+// with the 16, 24 or 32 byte pre-shared key psk.
+func NewServerConn(conn net.Conn, psk []byte) (*Conn, error) {
+	return newConn(conn, psk, false)
+}
+
+func newConn(conn net.Conn, psk []byte, isClient bool) (*Conn, error) {
+	switch len(psk) {
+	case 16, 24, 32:
+		break
+	default:
+		return nil, KeySizeError(len(psk))
+	}
+	return &Conn{conn: conn, psk: append([]byte(nil), psk...), isClient: isClient}, nil
+}
+
+// Handshake runs the key exchange if it has not happened yet.
+func (c *Conn) Handshake() error {
+	if c.handshaked.Load() {
+		return nil
+	}
+
+	c.handshakeMu.Lock()
+	defer c.handshakeMu.Unlock()
+	if c.handshaked.Load() || c.handshakeErr != nil {
+		return c.handshakeErr
+	}
+	c.handshakeErr = c.handshake()
+	c.handshaked.Store(c.handshakeErr == nil)
+	return c.handshakeErr
+}
+
+// handshake runs
+//
+//	client -> server: version || client nonce
+//	server -> client: version || server nonce || server finished
+//	client -> server: client finished
+//
+// where finished is the CMAC of both hellos under a key derived for it.
+func (c *Conn) handshake() error {
+	hello := make([]byte, connHelloLen)
+	hello[0] = connVersion
+	if _, err := rand.Read(hello[1:]); err != nil {
+		return err
+	}
+
+	var transcript []byte
+	var peer []byte
+	if c.isClient {
+		if _, err := c.conn.Write(hello); err != nil {
+			return err
+		}
+		peer = make([]byte, connHelloLen+BlockSize)
+		if err := c.readHandshake(peer); err != nil {
+			return err
+		}
+		transcript = append(hello, peer[:connHelloLen]...)
+	} else {
+		peer = make([]byte, connHelloLen)
+		if err := c.readHandshake(peer); err != nil {
+			return err
+		}
+		transcript = append(peer, hello...)
+	}
+	if peer[0] != connVersion {
+		return errConnHandshake
+	}
+
+	clientFinished, err := c.finished(connLabelClientFinished, transcript)
+	if err != nil {
+		return err
+	}
+	serverFinished, err := c.finished(connLabelServerFinished, transcript)
+	if err != nil {
+		return err
+	}
+
+	if c.isClient {
+		if subtle.ConstantTimeCompare(serverFinished, peer[connHelloLen:]) != 1 {
+			return errConnHandshake
+		}
+		if _, err := c.conn.Write(clientFinished); err != nil {
+			return err
+		}
+	} else {
+		if _, err := c.conn.Write(append(hello, serverFinished...)); err != nil {
+			return err
+		}
+		tag := make([]byte, BlockSize)
+		if err := c.readHandshake(tag); err != nil {
+			return err
+		}
+		if subtle.ConstantTimeCompare(clientFinished, tag) != 1 {
+			return errConnHandshake
+		}
+	}
+
+	clientKey, err := DeriveKey(c.psk, connLabelClientWrite, transcript, len(c.psk))
+	if err != nil {
+		return err
+	}
+	serverKey, err := DeriveKey(c.psk, connLabelServerWrite, transcript, len(c.psk))
+	if err != nil {
+		return err
+	}
+	if !c.isClient {
+		clientKey, serverKey = serverKey, clientKey
+	}
+	if err := c.out.setKey(clientKey); err != nil {
+		return err
+	}
+	return c.in.setKey(serverKey)
+}
+
+func (c *Conn) readHandshake(buf []byte) error {
+	if _, err := io.ReadFull(c.conn, buf); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return errConnHandshake
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *Conn) finished(label, transcript []byte) ([]byte, error) {
+	key, err := DeriveKey(c.psk, label, nil, len(c.psk))
+	if err != nil {
+		return nil, err
+	}
+	mac, err := NewCMAC(key)
+	if err != nil {
+		return nil, err
+	}
+	mac.Write(transcript)
+	return mac.Sum(nil), nil
+}
+
+// Read reads decrypted application data. It returns io.EOF once the peer has
+// closed the channel, and an error if the underlying connection ends without
+// a close record.
+func (c *Conn) Read(p []byte) (int, error) {
+	if err := c.Handshake(); err != nil {
+		return 0, err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	c.in.Lock()
+	defer c.in.Unlock()
+	for len(c.input) == 0 {
+		if c.in.err != nil {
+			return 0, c.in.err
+		}
+		c.in.err = c.readRecord()
+	}
+	n := copy(p, c.input)
+	c.input = c.input[n:]
+	return n, nil
+}
+
+func (c *Conn) readRecord() error {
+	var header [2]byte
+	if _, err := io.ReadFull(c.conn, header[:]); err != nil {
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	n := int(binary.BigEndian.Uint16(header[:]))
+	if n < 1+c.in.aead.Overhead() || n > 1+connMaxPlaintext+c.in.aead.Overhead() {
+		return errConnRecord
+	}
+	if cap(c.in.buf) < n {
+		c.in.buf = make([]byte, 1+connMaxPlaintext+c.in.aead.Overhead())
+	}
+	record := c.in.buf[:n]
+	if _, err := io.ReadFull(c.conn, record); err != nil {
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+
+	plaintext, err := c.in.aead.Open(record[:0], c.in.nextNonce(), record, header[:])
+	if err != nil {
+		return errConnRecord
+	}
+	switch plaintext[0] {
+	case connRecordData:
+		c.input = plaintext[1:]
+	case connRecordKeyUpdate:
+		if len(plaintext) != 1 {
+			return errConnRecord
+		}
+		return c.in.update()
+	case connRecordClose:
+		return io.EOF
+	default:
+		return errConnRecord
+	}
+	return nil
+}
+
+// Write encrypts p and sends it in one or more records.
+func (c *Conn) Write(p []byte) (int, error) {
+	if err := c.Handshake(); err != nil {
+		return 0, err
+	}
+
+	c.out.Lock()
+	defer c.out.Unlock()
+	if c.out.err != nil {
+		return 0, c.out.err
+	}
+
+	written := 0
+	for len(p) > 0 {
+		n := len(p)
+		if n > connMaxPlaintext {
+			n = connMaxPlaintext
+		}
+		if err := c.writeRecord(connRecordData, p[:n]); err != nil {
+			c.out.err = err
+			return written, err
+		}
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+func (c *Conn) writeRecord(typ byte, data []byte) error {
+	if c.out.seq == connRekeyInterval-1 {
+		if err := c.sealRecord(connRecordKeyUpdate, nil); err != nil {
+			return err
+		}
+		if err := c.out.update(); err != nil {
+			return err
+		}
+	}
+	return c.sealRecord(typ, data)
+}
+
+// sealRecord sends [len]_16 || Seal(type || data), authenticating the length.
+func (c *Conn) sealRecord(typ byte, data []byte) error {
+	n := 1 + len(data) + c.out.aead.Overhead()
+	record := append(c.out.buf[:0], byte(n>>8), byte(n), typ)
+	record = append(record, data...)
+	record = c.out.aead.Seal(record[:2], c.out.nextNonce(), record[2:], record[:2])
+	c.out.buf = record
+	_, err := c.conn.Write(record)
+	return err
+}
+
+// Close sends a close record if the handshake has completed and no Write is
+// in progress, then closes the underlying connection. Sending the close record
+// gives up after a timeout, so Close returns even if the peer is not reading.
+// It does not wait for a handshake in progress, which fails once the
+// connection is closed.
+func (c *Conn) Close() error {
+	var err error
+	if c.handshaked.Load() && c.out.TryLock() {
+		if c.out.err == nil {
+			c.conn.SetWriteDeadline(time.Now().Add(connCloseTimeout))
+			err = c.writeRecord(connRecordClose, nil)
+			c.out.err = errConnClosed
+		}
+		c.out.Unlock()
+	}
+	return errors.Join(err, c.conn.Close())
+}
+
+func (c *Conn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+func (c *Conn) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+func (h *halfConn) setKey(key []byte) error {
+	b, err := NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aead, err := NewOCB(b)
+	if err != nil {
+		return err
+	}
+	h.key = key
+	h.aead = aead
+	h.seq = 0
+	h.nonce = make([]byte, aead.NonceSize())
+	return nil
+}
+
+// update replaces the key with one derived from it, so earlier records stay
+// protected if a later key leaks.
+func (h *halfConn) update() error {
+	key, err := DeriveKey(h.key, connLabelKeyUpdate, nil, len(h.key))
+	if err != nil {
+		return err
+	}
+	return h.setKey(key)
+}
+
+// nextNonce returns the nonce for the next record: the sequence number,
+// big-endian and left-padded with zeros.
+func (h *halfConn) nextNonce() []byte {
+	binary.BigEndian.PutUint64(h.nonce[len(h.nonce)-8:], h.seq)
+	h.seq++
+	return h.nonce
+}
@@ -0,0 +1,221 @@
+package sealion
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func connPair(t *testing.T, clientKey, serverKey []byte) (*Conn, *Conn) {
+	t.Helper()
+	a, b := net.Pipe()
+	client, err := NewClientConn(a, clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := NewServerConn(b, serverKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, server
+}
+
+// handshakePair completes the handshake on both ends.
+func handshakePair(t *testing.T, client, server *Conn) {
+	t.Helper()
+	errc := make(chan error, 1)
+	go func() { errc <- client.Handshake() }()
+	if err := server.Handshake(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConn(t *testing.T) {
+	psk := make([]byte, 32)
+	client, server := connPair(t, psk, psk)
+
+	data := bytes.Repeat([]byte("0123456789"), 5000)
+	errc := make(chan error, 1)
+	go func() {
+		// Echo the data back
+		buf := make([]byte, len(data))
+		_, err := io.ReadFull(server, buf)
+		if err == nil {
+			_, err = server.Write(buf)
+		}
+		errc <- err
+	}()
+
+	if _, err := client.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	got := make([]byte, len(data))
+	if _, err := io.ReadFull(client, got); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("echoed data differs")
+	}
+
+	// Close sends a close record, which the peer reads as io.EOF
+	go client.Close()
+	if n, err := server.Read(make([]byte, 1)); n != 0 || err != io.EOF {
+		t.Errorf("Read after peer Close returned %d, %v", n, err)
+	}
+	server.Close()
+}
+
+func TestConnRekey(t *testing.T) {
+	psk := make([]byte, 16)
+	client, server := connPair(t, psk, psk)
+	handshakePair(t, client, server)
+
+	// Skip ahead to just before the rekey interval on both ends
+	client.out.seq = connRekeyInterval - 3
+	server.in.seq = connRekeyInterval - 3
+	firstKey := client.out.key
+
+	data := make([]byte, 6*connMaxPlaintext)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	go func() {
+		client.Write(data)
+		client.Close()
+	}()
+	got, err := io.ReadAll(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("data differs across the rekey")
+	}
+	if bytes.Equal(client.out.key, firstKey) || !bytes.Equal(client.out.key, server.in.key) {
+		t.Error("keys were not updated in step")
+	}
+	if server.in.seq > 10 {
+		t.Errorf("sequence number %d was not reset", server.in.seq)
+	}
+}
+
+func TestConnWrongKey(t *testing.T) {
+	client, server := connPair(t, make([]byte, 32), bytes.Repeat([]byte{1}, 32))
+	go func() {
+		server.Handshake()
+		server.Close()
+	}()
+	if err := client.Handshake(); !errors.Is(err, errConnHandshake) {
+		t.Errorf("client handshake gave %v", err)
+	}
+	if _, err := client.Write([]byte("x")); err == nil {
+		t.Error("Write after a failed handshake succeeded")
+	}
+}
+
+func TestConnTruncation(t *testing.T) {
+	psk := make([]byte, 24)
+	client, server := connPair(t, psk, psk)
+	go func() {
+		client.Write([]byte("partial"))
+		// Close the transport without a close record
+		client.conn.Close()
+	}()
+	got, err := io.ReadAll(server)
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("truncated connection gave %v", err)
+	}
+	if string(got) != "partial" {
+		t.Errorf("read %q before the truncation", got)
+	}
+}
+
+// flipConn flips one bit of the byte at offset at of the read stream.
+type flipConn struct {
+	net.Conn
+	at, read int
+}
+
+func (c *flipConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if c.at >= c.read && c.at < c.read+n {
+		p[c.at-c.read] ^= 1
+	}
+	c.read += n
+	return n, err
+}
+
+func TestConnTamper(t *testing.T) {
+	psk := make([]byte, 16)
+	a, b := net.Pipe()
+	client, _ := NewClientConn(a, psk)
+	// Past the client hello and finished, inside the first record
+	server, _ := NewServerConn(&flipConn{Conn: b, at: connHelloLen + BlockSize + 5}, psk)
+
+	go client.Write([]byte("hello"))
+	if _, err := server.Read(make([]byte, 5)); !errors.Is(err, errConnRecord) {
+		t.Errorf("tampered record gave %v", err)
+	}
+	client.conn.Close()
+}
+
+func TestConnCloseDuringHandshake(t *testing.T) {
+	// The peer never answers, so the handshake blocks in I/O
+	a, _ := net.Pipe()
+	client, _ := NewClientConn(a, make([]byte, 16))
+	errc := make(chan error, 1)
+	go func() { errc <- client.Handshake() }()
+	time.Sleep(10 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		client.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close blocked on the handshake")
+	}
+	if err := <-errc; err == nil {
+		t.Error("handshake succeeded on a closed connection")
+	}
+}
+
+func TestConnCloseWithoutReader(t *testing.T) {
+	// Neither end reads, so the close records can never be delivered
+	client, server := connPair(t, make([]byte, 16), make([]byte, 16))
+	handshakePair(t, client, server)
+
+	done := make(chan struct{})
+	go func() {
+		client.Close()
+		server.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(3 * connCloseTimeout):
+		t.Fatal("Close blocked on a peer that is not reading")
+	}
+	if _, err := client.Write([]byte("x")); err == nil {
+		t.Error("Write succeeded after Close")
+	}
+}
+
+func TestNewConnKeySize(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	if _, err := NewClientConn(a, make([]byte, 20)); err == nil {
+		t.Error("accepted a 20-byte key")
+	}
+}
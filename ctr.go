@@ -0,0 +1,76 @@
+package sealion
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+)
+
+// Number of keystream blocks generated per refill, a multiple of four
+const ctrStreamBlocks = 32
+
+type ctr struct {
+	subkeys *[40]uint32
+	hi, lo  uint64 // next counter block, big-endian
+	out     [ctrStreamBlocks * BlockSize]byte
+	used    int // keystream bytes of out already consumed
+}
+
+// NewCTR returns a cipher.Stream encrypting with SEA-Lion in counter mode. The
+// counter starts at iv and is incremented as a 128-bit big-endian integer,
+// exactly as cipher.NewCTR does, but keystream is generated many blocks at a
+// time straight from the key schedule.
+func NewCTR(key, iv []byte) (cipher.Stream, error) {
+	b, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return b.(*seaLionCipher).NewCTR(iv), nil
+}
+
+// NewCTR lets cipher.NewCTR pick up the native counter mode implementation.
+func (s *seaLionCipher) NewCTR(iv []byte) cipher.Stream {
+	if len(iv) != BlockSize {
+		panic("sealion: IV length must equal block size")
+	}
+	c := &ctr{
+		subkeys: &s.subkeys,
+		hi:      binary.BigEndian.Uint64(iv[:8]),
+		lo:      binary.BigEndian.Uint64(iv[8:]),
+	}
+	c.used = len(c.out)
+	return c
+}
+
+func (c *ctr) refill() {
+	for i := 0; i < ctrStreamBlocks; i++ {
+		binary.BigEndian.PutUint64(c.out[i*BlockSize:], c.hi)
+		binary.BigEndian.PutUint64(c.out[i*BlockSize+8:], c.lo)
+		c.lo++
+		if c.lo == 0 {
+			c.hi++
+		}
+	}
+	for i := 0; i < ctrStreamBlocks; i += 4 {
+		encryptBlocks4(c.subkeys, c.out[i*BlockSize:], c.out[i*BlockSize:])
+	}
+	c.used = 0
+}
+
+func (c *ctr) XORKeyStream(dst, src []byte) {
+	if len(dst) < len(src) {
+		panic("sealion: output smaller than input")
+	}
+	if inexactOverlap(dst[:len(src)], src) {
+		panic("sealion: invalid buffer overlap")
+	}
+
+	for len(src) > 0 {
+		if c.used == len(c.out) {
+			c.refill()
+		}
+		n := subtle.XORBytes(dst, src, c.out[c.used:])
+		c.used += n
+		dst, src = dst[n:], src[n:]
+	}
+}
@@ -0,0 +1,294 @@
+package sealion
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+)
+
+const (
+	// Requests between reseeds before one is forced
+	drbgReseedInterval = 1 << 48
+	// Maximum number of bytes returned by a single Generate call
+	drbgMaxRequest = 1 << 16
+	// Maximum length of personalization strings and additional input
+	drbgMaxInput = 1 << 27
+)
+
+var (
+	// ErrDRBGEntropy is returned when the entropy source fails or returns too
+	// little data.
+	ErrDRBGEntropy = errors.New("sealion: DRBG entropy source failed")
+	// ErrDRBGHealthTest is returned when a continuous health test fails. The
+	// DRBG refuses to produce further output once this has happened.
+	ErrDRBGHealthTest = errors.New("sealion: DRBG health test failed")
+
+	errDRBGRequest = errors.New("sealion: DRBG request too large")
+)
+
+// CTRDRBG is a CTR_DRBG deterministic random bit generator as specified in
+// NIST SP 800-90A, using SEA-Lion with the block cipher derivation function.
+// It is safe for concurrent use and implements io.Reader.
+type CTRDRBG struct {
+	mu       sync.Mutex
+	entropy  io.Reader
+	newBlock func(key []byte) (cipher.Block, error)
+	keySize  int
+
+	b       cipher.Block
+	v       [BlockSize]byte
+	counter uint64
+
+	predictionResistance bool
+	lastEntropy          []byte
+	failed               bool
+}
+
+// NewCTRDRBG instantiates a CTR_DRBG with a SEA-Lion key of keySize bytes,
+// seeded from entropy (for example crypto/rand.Reader) and the optional
+// personalization string. With predictionResistance set, every Generate call
+// reseeds from entropy first.
+func NewCTRDRBG(entropy io.Reader, keySize int, personalization []byte, predictionResistance bool) (*CTRDRBG, error) {
+	switch keySize {
+	case 16, 24, 32:
+		break
+	default:
+		return nil, KeySizeError(keySize)
+	}
+	return newCTRDRBG(entropy, keySize, NewCipher, personalization, predictionResistance)
+}
+
+func newCTRDRBG(entropy io.Reader, keySize int, newBlock func([]byte) (cipher.Block, error), personalization []byte, predictionResistance bool) (*CTRDRBG, error) {
+	if len(personalization) > drbgMaxInput {
+		return nil, errDRBGRequest
+	}
+
+	d := &CTRDRBG{
+		entropy:              entropy,
+		newBlock:             newBlock,
+		keySize:              keySize,
+		predictionResistance: predictionResistance,
+	}
+
+	// seed_material = df(entropy_input || nonce || personalization_string)
+	entropyInput, err := d.readEntropy(keySize + keySize/2)
+	if err != nil {
+		return nil, err
+	}
+	seed := d.df(append(entropyInput, personalization...))
+
+	// Key = 0^keylen, V = 0^blocklen
+	if d.b, err = newBlock(make([]byte, keySize)); err != nil {
+		return nil, err
+	}
+	d.update(seed)
+	d.counter = 1
+	return d, nil
+}
+
+// Reseed mixes fresh entropy and the optional additional input into the
+// internal state.
+func (d *CTRDRBG) Reseed(additionalInput []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.failed {
+		return ErrDRBGHealthTest
+	}
+	return d.reseed(additionalInput)
+}
+
+// Generate fills out with pseudorandom bytes, mixing in the optional
+// additional input. out may be at most 64 KiB.
+func (d *CTRDRBG) Generate(out, additionalInput []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.failed {
+		return ErrDRBGHealthTest
+	}
+	return d.generate(out, additionalInput)
+}
+
+// Read fills p with pseudorandom bytes, splitting large reads into several
+// requests.
+func (d *CTRDRBG) Read(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.failed {
+		return 0, ErrDRBGHealthTest
+	}
+
+	n := 0
+	for n < len(p) {
+		chunk := p[n:]
+		if len(chunk) > drbgMaxRequest {
+			chunk = chunk[:drbgMaxRequest]
+		}
+		if err := d.generate(chunk, nil); err != nil {
+			return n, err
+		}
+		n += len(chunk)
+	}
+	return n, nil
+}
+
+func (d *CTRDRBG) reseed(additionalInput []byte) error {
+	if len(additionalInput) > drbgMaxInput {
+		return errDRBGRequest
+	}
+
+	// seed_material = df(entropy_input || additional_input)
+	entropyInput, err := d.readEntropy(d.keySize)
+	if err != nil {
+		return err
+	}
+	d.update(d.df(append(entropyInput, additionalInput...)))
+	d.counter = 1
+	return nil
+}
+
+func (d *CTRDRBG) generate(out, additionalInput []byte) error {
+	if len(out) > drbgMaxRequest || len(additionalInput) > drbgMaxInput {
+		return errDRBGRequest
+	}
+
+	if d.predictionResistance || d.counter > drbgReseedInterval {
+		if err := d.reseed(additionalInput); err != nil {
+			return err
+		}
+		additionalInput = nil
+	}
+
+	provided := make([]byte, d.seedSize())
+	if len(additionalInput) > 0 {
+		provided = d.df(additionalInput)
+		d.update(provided)
+	}
+
+	var block [BlockSize]byte
+	for n := 0; n < len(out); {
+		incrementCounter(&d.v)
+		d.b.Encrypt(block[:], d.v[:])
+		n += copy(out[n:], block[:])
+	}
+
+	d.update(provided)
+	d.counter++
+	return nil
+}
+
+// update is CTR_DRBG_Update: it encrypts successive counter values to
+// produce seedlen bytes, XORs in provided and takes the new Key and V.
+func (d *CTRDRBG) update(provided []byte) {
+	temp := make([]byte, 0, d.seedSize()+BlockSize)
+	var block [BlockSize]byte
+	for len(temp) < d.seedSize() {
+		incrementCounter(&d.v)
+		d.b.Encrypt(block[:], d.v[:])
+		temp = append(temp, block[:]...)
+	}
+	temp = temp[:d.seedSize()]
+	subtle.XORBytes(temp, temp, provided)
+
+	b, err := d.newBlock(temp[:d.keySize])
+	if err != nil {
+		panic(err)
+	}
+	d.b = b
+	copy(d.v[:], temp[d.keySize:])
+}
+
+// df is the Block_Cipher_df derivation function, compressing input to
+// seedlen bytes.
+func (d *CTRDRBG) df(input []byte) []byte {
+	seedSize := d.seedSize()
+
+	// S = L || N || input || 0x80, zero padded to a multiple of the block size
+	s := make([]byte, BlockSize+8, BlockSize+8+len(input)+1+BlockSize)
+	binary.BigEndian.PutUint32(s[BlockSize:], uint32(len(input)))
+	binary.BigEndian.PutUint32(s[BlockSize+4:], uint32(seedSize))
+	s = append(s, input...)
+	s = append(s, 0x80)
+	for len(s)%BlockSize != 0 {
+		s = append(s, 0)
+	}
+
+	// K = leftmost keylen bytes of 0x00010203...1F
+	key := make([]byte, d.keySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	b, err := d.newBlock(key)
+	if err != nil {
+		panic(err)
+	}
+
+	// temp = BCC(K, [i]_32 || 0 || S) for i = 0, 1, ...; the IV block is the
+	// first block of s
+	temp := make([]byte, 0, seedSize+BlockSize)
+	for i := uint32(0); len(temp) < seedSize; i++ {
+		for j := range s[:BlockSize] {
+			s[j] = 0
+		}
+		binary.BigEndian.PutUint32(s[:4], i)
+
+		var chain [BlockSize]byte
+		for data := s; len(data) > 0; data = data[BlockSize:] {
+			subtle.XORBytes(chain[:], chain[:], data[:BlockSize])
+			b.Encrypt(chain[:], chain[:])
+		}
+		temp = append(temp, chain[:]...)
+	}
+
+	// K = leftmost keylen bytes of temp, X = next blocklen bytes; output
+	// successive encryptions of X
+	b, err = d.newBlock(temp[:d.keySize])
+	if err != nil {
+		panic(err)
+	}
+	var x [BlockSize]byte
+	copy(x[:], temp[d.keySize:])
+	out := make([]byte, 0, seedSize+BlockSize)
+	for len(out) < seedSize {
+		b.Encrypt(x[:], x[:])
+		out = append(out, x[:]...)
+	}
+	return out[:seedSize]
+}
+
+// readEntropy reads n bytes from the entropy source and runs the repetition
+// health test, which fails if the source returns the same input twice in a
+// row.
+func (d *CTRDRBG) readEntropy(n int) ([]byte, error) {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(d.entropy, buf); err != nil {
+		return nil, ErrDRBGEntropy
+	}
+
+	last := d.lastEntropy
+	if len(last) > n {
+		last = last[:n]
+	}
+	if len(last) == n && subtle.ConstantTimeCompare(last, buf) == 1 {
+		d.failed = true
+		return nil, ErrDRBGHealthTest
+	}
+	d.lastEntropy = append(d.lastEntropy[:0], buf...)
+	return buf, nil
+}
+
+func (d *CTRDRBG) seedSize() int {
+	return d.keySize + BlockSize
+}
+
+// incrementCounter adds one to v as a 128-bit big-endian integer.
+func incrementCounter(v *[BlockSize]byte) {
+	for i := BlockSize - 1; i >= 0; i-- {
+		v[i]++
+		if v[i] != 0 {
+			return
+		}
+	}
+}
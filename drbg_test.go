@@ -0,0 +1,94 @@
+package sealion
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+)
+
+// NIST CAVP CTR_DRBG AES-128 use df, no prediction resistance, count 0, with
+// AES in place of SEA-Lion
+func TestCTRDRBGAES(t *testing.T) {
+	entropy := bytes.NewReader(fromHex("890eb067acf7382eff80b0c73bc872c6" + "aad471ef3ef1d203"))
+	d, err := newCTRDRBG(entropy, 16, aes.NewCipher, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make([]byte, 64)
+	for i := 0; i < 2; i++ {
+		if err := d.Generate(out, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := fromHex("a5514ed7095f64f3d0d3a5760394ab42062f373a25072a6ea6bcfd8489e94af6cf18659fea22ed1ca0a9e33f718b115ee536b12809c31b72b08ddd8be1910fa3")
+	if !bytes.Equal(out, want) {
+		t.Errorf("got %x, want %x", out, want)
+	}
+}
+
+func TestCTRDRBG(t *testing.T) {
+	for _, size := range []int{16, 24, 32} {
+		d, err := NewCTRDRBG(rand.Reader, size, []byte("personalization"), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, b := make([]byte, 100), make([]byte, 100)
+		d.Generate(a, nil)
+		d.Generate(b, []byte("additional input"))
+		if bytes.Equal(a, b) {
+			t.Errorf("key size %d: repeated output", size)
+		}
+		if err := d.Reseed(nil); err != nil {
+			t.Errorf("key size %d: Reseed: %v", size, err)
+		}
+
+		// Read splits requests above the Generate limit
+		big := make([]byte, 3*drbgMaxRequest+1)
+		if n, err := d.Read(big); n != len(big) || err != nil {
+			t.Errorf("key size %d: Read returned %d, %v", size, n, err)
+		}
+		if err := d.Generate(big, nil); err == nil {
+			t.Errorf("key size %d: Generate accepted an oversized request", size)
+		}
+	}
+
+	if _, err := NewCTRDRBG(rand.Reader, 20, nil, false); err == nil {
+		t.Error("accepted a 20-byte key size")
+	}
+}
+
+func TestCTRDRBGEntropyFailure(t *testing.T) {
+	if _, err := NewCTRDRBG(bytes.NewReader(nil), 16, nil, false); !errors.Is(err, ErrDRBGEntropy) {
+		t.Errorf("empty entropy source gave %v", err)
+	}
+
+	// Prediction resistance reseeds on every request and runs out of entropy
+	d, err := NewCTRDRBG(io.LimitReader(rand.Reader, 16+8), 16, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Generate(make([]byte, 16), nil); !errors.Is(err, ErrDRBGEntropy) {
+		t.Errorf("exhausted entropy source gave %v", err)
+	}
+}
+
+func TestCTRDRBGHealthTest(t *testing.T) {
+	// A stuck source repeats its output and must shut the DRBG down
+	d, err := NewCTRDRBG(bytes.NewReader(make([]byte, 1000)), 32, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make([]byte, 16)
+	if err := d.Generate(out, nil); !errors.Is(err, ErrDRBGHealthTest) {
+		t.Errorf("Generate gave %v", err)
+	}
+	if _, err := d.Read(out); !errors.Is(err, ErrDRBGHealthTest) {
+		t.Errorf("Read after failure gave %v", err)
+	}
+	if err := d.Reseed(nil); !errors.Is(err, ErrDRBGHealthTest) {
+		t.Errorf("Reseed after failure gave %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package sealion
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+)
+
+const (
+	eaxNonceSize  = 16
+	eaxTagSize    = 16
+	eaxMinTagSize = 4
+)
+
+// OMAC tweaks separating the nonce, header and ciphertext MACs
+const (
+	eaxNonceTweak = iota
+	eaxHeaderTweak
+	eaxCiphertextTweak
+)
+
+type eax struct {
+	b       cipher.Block
+	k1, k2  [BlockSize]byte
+	tagSize int
+}
+
+// NewEAX returns the EAX mode of the given 128-bit block cipher with a 16-byte
+// tag. Nonces may be of any length; NonceSize reports the recommended 16 bytes.
+func NewEAX(b cipher.Block) (cipher.AEAD, error) {
+	return NewEAXWithTagSize(b, eaxTagSize)
+}
+
+// NewEAXWithTagSize returns the EAX mode of the given 128-bit block cipher
+// producing tags of tagSize bytes (4 to 16).
+func NewEAXWithTagSize(b cipher.Block, tagSize int) (cipher.AEAD, error) {
+	if b.BlockSize() != BlockSize {
+		return nil, errors.New("sealion: EAX requires a 128-bit block cipher")
+	}
+	if tagSize < eaxMinTagSize || tagSize > eaxTagSize {
+		return nil, errors.New("sealion: invalid EAX tag size")
+	}
+
+	e := &eax{b: b, tagSize: tagSize}
+	e.k1, e.k2 = cmacSubkeys(b)
+	return e, nil
+}
+
+func (e *eax) NonceSize() int {
+	return eaxNonceSize
+}
+
+func (e *eax) Overhead() int {
+	return e.tagSize
+}
+
+func (e *eax) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	ret, out := sliceForAppend(dst, len(plaintext)+e.tagSize)
+	if inexactOverlap(out, plaintext) {
+		panic("sealion: invalid buffer overlap")
+	}
+
+	// N' = OMAC_K^0(N), C = CTR_K^N'(M)
+	n := e.omac(eaxNonceTweak, nonce)
+	cipher.NewCTR(e.b, n[:]).XORKeyStream(out, plaintext)
+
+	tag := e.tag(&n, additionalData, out[:len(plaintext)])
+	copy(out[len(plaintext):], tag[:e.tagSize])
+	return ret
+}
+
+func (e *eax) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < e.tagSize {
+		return nil, errOpen
+	}
+
+	tag := ciphertext[len(ciphertext)-e.tagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-e.tagSize]
+
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	if inexactOverlap(out, ciphertext) {
+		panic("sealion: invalid buffer overlap")
+	}
+
+	n := e.omac(eaxNonceTweak, nonce)
+	expected := e.tag(&n, additionalData, ciphertext)
+	if subtle.ConstantTimeCompare(expected[:e.tagSize], tag) != 1 {
+		return nil, errOpen
+	}
+
+	cipher.NewCTR(e.b, n[:]).XORKeyStream(out, ciphertext)
+	return ret, nil
+}
+
+// tag computes T = N' xor OMAC_K^1(H) xor OMAC_K^2(C).
+func (e *eax) tag(n *[BlockSize]byte, header, ciphertext []byte) [BlockSize]byte {
+	h := e.omac(eaxHeaderTweak, header)
+	c := e.omac(eaxCiphertextTweak, ciphertext)
+	subtle.XORBytes(h[:], h[:], n[:])
+	subtle.XORBytes(h[:], h[:], c[:])
+	return h
+}
+
+// omac computes OMAC_K^t(msg) = CMAC_K([t]_n || msg).
+func (e *eax) omac(t byte, msg []byte) [BlockSize]byte {
+	var x [BlockSize]byte
+	x[BlockSize-1] = t
+	if len(msg) == 0 {
+		return cmacSum(e.b, &e.k1, &e.k2, x[:])
+	}
+	e.b.Encrypt(x[:], x[:])
+	return cmacChain(e.b, &e.k1, &e.k2, x, msg)
+}
@@ -0,0 +1,46 @@
+package sealion
+
+import (
+	"crypto/aes"
+	"testing"
+)
+
+// Test vectors from the EAX paper (Bellare, Rogaway and Wagner), with AES in
+// place of SEA-Lion
+var eaxAESTests = []struct {
+	key, nonce, header, msg, ciphertext string
+}{
+	{"233952DEE4D5ED5F9B9C6D6FF80FF478", "62EC67F9C3A4A407FCB2A8C49031A8B3", "6BFB914FD07EAE6B", "", "E037830E8389F27B025A2D6527E79D01"},
+	{"91945D3F4DCBEE0BF45EF52255F095A4", "BECAF043B0A23D843194BA972C66DEBD", "FA3BFD4806EB53FA", "F7FB", "19DD5C4C9331049D0BDAB0277408F67967E5"},
+	{"01F74AD64077F2E704C0F60ADA3DD523", "70C3DB4F0D26368400A10ED05D2BFF5E", "234A3463C1264AC6", "1A47CB4933", "D851D5BAE03A59F238A23E39199DC9266626C40F80"},
+}
+
+func TestEAXAES(t *testing.T) {
+	for i, tt := range eaxAESTests {
+		b, _ := aes.NewCipher(fromHex(tt.key))
+		eax, err := NewEAX(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkAEAD(t, i, eax, tt.nonce, tt.header, tt.msg, tt.ciphertext)
+	}
+}
+
+func TestEAX(t *testing.T) {
+	b, _ := NewCipher(make([]byte, 32))
+	eax, err := NewEAX(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testAEAD(t, eax)
+
+	short, err := NewEAXWithTagSize(b, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testAEAD(t, short)
+
+	if _, err := NewEAXWithTagSize(b, 3); err == nil {
+		t.Error("accepted a 3-byte tag")
+	}
+}
@@ -0,0 +1,83 @@
+package sealion
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+const (
+	encStreamVersion   = 0x01
+	encStreamNonceSize = 16
+	encStreamHeaderLen = 1 + encStreamNonceSize
+)
+
+var (
+	encStreamLabel = []byte("sealion encrypted stream")
+
+	errEncStreamHeader = errors.New("sealion: invalid encrypted stream header")
+)
+
+// NewEncryptWriter returns an io.WriteCloser that encrypts an arbitrary-length
+// stream to w under key. It first writes a header of a version byte and a
+// random 16-byte nonce, then the data as authenticated chunks following
+// NewStreamWriter, sealed with OCB under a key derived from key and the
+// header. Close must be called to seal the final chunk; it does not close w.
+func NewEncryptWriter(w io.Writer, key []byte) (io.WriteCloser, error) {
+	header := make([]byte, encStreamHeaderLen)
+	header[0] = encStreamVersion
+	if _, err := rand.Read(header[1:]); err != nil {
+		return nil, err
+	}
+
+	aead, prefix, err := encStreamAEAD(key, header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return NewStreamWriter(w, aead, prefix)
+}
+
+// NewDecryptReader reads the header written by NewEncryptWriter from r and
+// returns an io.Reader yielding the decrypted stream. Like NewStreamReader, it
+// returns an error instead of io.EOF if the stream was truncated or modified.
+func NewDecryptReader(r io.Reader, key []byte) (io.Reader, error) {
+	header := make([]byte, encStreamHeaderLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, errEncStreamHeader
+		}
+		return nil, err
+	}
+	if header[0] != encStreamVersion {
+		return nil, errEncStreamHeader
+	}
+
+	aead, prefix, err := encStreamAEAD(key, header)
+	if err != nil {
+		return nil, err
+	}
+	return NewStreamReader(r, aead, prefix)
+}
+
+// encStreamAEAD returns OCB under the key derived for the stream with
+// header. Every stream has its own key, so the STREAM nonce prefix is all
+// zeros.
+func encStreamAEAD(key, header []byte) (cipher.AEAD, []byte, error) {
+	streamKey, err := DeriveKey(key, encStreamLabel, header, len(key))
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := NewCipher(streamKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	aead, err := NewOCB(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	return aead, make([]byte, aead.NonceSize()-streamNonceSuffix), nil
+}
@@ -0,0 +1,83 @@
+package sealion
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func encryptStream(t *testing.T, key, plaintext []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewEncryptWriter(&buf, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decryptStream(key, ciphertext []byte) ([]byte, error) {
+	r, err := NewDecryptReader(bytes.NewReader(ciphertext), key)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestEncryptWriter(t *testing.T) {
+	key := make([]byte, 32)
+	for _, n := range []int{0, 1, streamChunkSize, streamChunkSize + 1, 3*streamChunkSize + 7} {
+		plaintext := make([]byte, n)
+		for i := range plaintext {
+			plaintext[i] = byte(i)
+		}
+		ct := encryptStream(t, key, plaintext)
+		if ct[0] != encStreamVersion {
+			t.Errorf("length %d: version byte %#x", n, ct[0])
+		}
+		got, err := decryptStream(key, ct)
+		if err != nil || !bytes.Equal(got, plaintext) {
+			t.Errorf("length %d: round trip failed: %v", n, err)
+		}
+	}
+
+	a := encryptStream(t, key, []byte("same input"))
+	b := encryptStream(t, key, []byte("same input"))
+	if bytes.Equal(a, b) {
+		t.Error("two streams are identical")
+	}
+}
+
+func TestDecryptReaderRejects(t *testing.T) {
+	key := make([]byte, 16)
+	ct := encryptStream(t, key, make([]byte, 2*streamChunkSize+5))
+
+	if _, err := decryptStream(bytes.Repeat([]byte{1}, 16), ct); err == nil {
+		t.Error("decrypted with the wrong key")
+	}
+
+	// The header selects the stream key, so changing the nonce fails
+	modified := append([]byte(nil), ct...)
+	modified[5] ^= 1
+	if _, err := decryptStream(key, modified); err == nil {
+		t.Error("decrypted with a modified nonce")
+	}
+	modified = append([]byte(nil), ct...)
+	modified[0] = 0x02
+	if _, err := decryptStream(key, modified); err == nil {
+		t.Error("decrypted with an unknown version")
+	}
+
+	segment := streamChunkSize + 16
+	for _, n := range []int{0, encStreamHeaderLen - 1, encStreamHeaderLen, encStreamHeaderLen + segment, len(ct) - 1} {
+		if _, err := decryptStream(key, ct[:n]); err == nil {
+			t.Errorf("stream truncated to %d bytes was accepted", n)
+		}
+	}
+}
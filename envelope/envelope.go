@@ -0,0 +1,144 @@
+// Package envelope implements envelope encryption with SEA-Lion: every object
+// is encrypted under a fresh data key, and the data key is wrapped under a
+// long-lived key-encryption key (KEK). Both travel together in one
+// self-describing blob.
+package envelope
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+
+	"github.com/Sid-Sun/sealion"
+)
+
+// Blob layout:
+//
+//	version (1) || aead id (1) || wrap id (1) || wrapped key length (2) ||
+//	wrapped key || nonce length (1) || nonce || ciphertext
+//
+// Everything before the ciphertext is authenticated as associated data.
+const (
+	version1 = 0x01
+
+	// AEAD used for payloads: SEA-Lion-256 in GCM-SIV mode
+	aeadGCMSIV256 = 0x01
+	// Data key protection: RFC 3394 key wrap under the KEK
+	wrapKW = 0x01
+
+	dataKeySize = 32
+	fixedHeader = 5
+)
+
+var (
+	// ErrInvalidBlob is returned when a blob is truncated or malformed.
+	ErrInvalidBlob = errors.New("envelope: invalid blob")
+	// ErrUnsupported is returned when a blob uses an unknown version or
+	// algorithm.
+	ErrUnsupported = errors.New("envelope: unsupported version or algorithm")
+	// ErrDecrypt is returned when the data key cannot be unwrapped with the
+	// KEK or the payload fails authentication.
+	ErrDecrypt = errors.New("envelope: decryption failed")
+)
+
+// Seal generates a fresh data key, encrypts plaintext and additionalData
+// with it and returns a blob holding the data key wrapped under kek together
+// with the ciphertext. additionalData is authenticated but not stored.
+func Seal(kek, plaintext, additionalData []byte) ([]byte, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+
+	wrapped, err := sealion.Wrap(kek, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, fixedHeader, fixedHeader+len(wrapped)+1+aead.NonceSize())
+	header[0] = version1
+	header[1] = aeadGCMSIV256
+	header[2] = wrapKW
+	binary.BigEndian.PutUint16(header[3:5], uint16(len(wrapped)))
+	header = append(header, wrapped...)
+	header = append(header, byte(aead.NonceSize()))
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	header = append(header, nonce...)
+
+	blob := make([]byte, len(header), len(header)+len(plaintext)+aead.Overhead())
+	copy(blob, header)
+	return aead.Seal(blob, nonce, plaintext, associatedData(header, additionalData)), nil
+}
+
+// Open unwraps the data key of blob with kek and decrypts the payload,
+// authenticating it against additionalData.
+func Open(kek, blob, additionalData []byte) ([]byte, error) {
+	if len(blob) < fixedHeader {
+		return nil, ErrInvalidBlob
+	}
+	if blob[0] != version1 || blob[1] != aeadGCMSIV256 || blob[2] != wrapKW {
+		return nil, ErrUnsupported
+	}
+
+	rest := blob[fixedHeader:]
+	wrappedLen := int(binary.BigEndian.Uint16(blob[3:5]))
+	if len(rest) < wrappedLen+1 {
+		return nil, ErrInvalidBlob
+	}
+	wrapped := rest[:wrappedLen]
+	nonceLen := int(rest[wrappedLen])
+	rest = rest[wrappedLen+1:]
+	if len(rest) < nonceLen {
+		return nil, ErrInvalidBlob
+	}
+	nonce := rest[:nonceLen]
+	ciphertext := rest[nonceLen:]
+	header := blob[:len(blob)-len(ciphertext)]
+
+	dataKey, err := sealion.Unwrap(kek, wrapped)
+	if err != nil {
+		if errors.Is(err, sealion.ErrKeyWrapIntegrity) {
+			return nil, ErrDecrypt
+		}
+		var lengthErr sealion.KeyWrapLengthError
+		if errors.As(err, &lengthErr) {
+			return nil, ErrInvalidBlob
+		}
+		return nil, err
+	}
+	if len(dataKey) != dataKeySize {
+		return nil, ErrInvalidBlob
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if nonceLen != aead.NonceSize() {
+		return nil, ErrInvalidBlob
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, associatedData(header, additionalData))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+func newAEAD(dataKey []byte) (cipher.AEAD, error) {
+	return sealion.NewGCMSIV(dataKey)
+}
+
+func associatedData(header, additionalData []byte) []byte {
+	ad := make([]byte, 0, len(header)+len(additionalData))
+	ad = append(ad, header...)
+	return append(ad, additionalData...)
+}
@@ -0,0 +1,77 @@
+package envelope
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/Sid-Sun/sealion"
+)
+
+func TestSealOpen(t *testing.T) {
+	kek := bytes.Repeat([]byte{0x42}, 32)
+	for _, n := range []int{0, 1, 1000} {
+		plaintext := bytes.Repeat([]byte{7}, n)
+		blob, err := Seal(kek, plaintext, []byte("object-1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Open(kek, blob, []byte("object-1"))
+		if err != nil || !bytes.Equal(got, plaintext) {
+			t.Errorf("length %d: round trip failed: %v", n, err)
+		}
+	}
+}
+
+func TestBlobLayout(t *testing.T) {
+	kek := make([]byte, 16)
+	a, _ := Seal(kek, []byte("payload"), nil)
+	b, _ := Seal(kek, []byte("payload"), nil)
+	if bytes.Equal(a, b) {
+		t.Error("two blobs are identical")
+	}
+
+	if a[0] != version1 || a[1] != aeadGCMSIV256 || a[2] != wrapKW {
+		t.Errorf("header %x", a[:3])
+	}
+	wrappedLen := int(binary.BigEndian.Uint16(a[3:5]))
+	dataKey, err := sealion.Unwrap(kek, a[fixedHeader:fixedHeader+wrappedLen])
+	if err != nil || len(dataKey) != dataKeySize {
+		t.Errorf("wrapped data key: %x, %v", dataKey, err)
+	}
+}
+
+func TestOpenRejects(t *testing.T) {
+	kek := make([]byte, 32)
+	blob, err := Seal(kek, []byte("payload"), []byte("ad"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(kek, blob, []byte("other")); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("wrong additional data gave %v", err)
+	}
+	if _, err := Open(bytes.Repeat([]byte{1}, 32), blob, []byte("ad")); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("wrong KEK gave %v", err)
+	}
+
+	for i := range blob {
+		modified := append([]byte(nil), blob...)
+		modified[i] ^= 1
+		if _, err := Open(kek, modified, []byte("ad")); err == nil {
+			t.Errorf("blob modified at byte %d was accepted", i)
+		}
+	}
+	for n := 0; n < len(blob); n++ {
+		if _, err := Open(kek, blob[:n], []byte("ad")); err == nil {
+			t.Errorf("blob truncated to %d bytes was accepted", n)
+		}
+	}
+
+	unsupported := append([]byte(nil), blob...)
+	unsupported[0] = 0x02
+	if _, err := Open(kek, unsupported, []byte("ad")); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("unknown version gave %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package sealion
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"math"
+	"math/big"
+)
+
+const (
+	ff1Rounds    = 10
+	ff1MaxLength = math.MaxUint32
+)
+
+// FF1 implements the FF1 format-preserving encryption mode of NIST SP 800-38G
+// with SEA-Lion as the underlying block cipher. Plaintexts and ciphertexts are
+// strings over a fixed alphabet and keep their length.
+type FF1 struct {
+	b        cipher.Block
+	alphabet *fpeAlphabet
+}
+
+// NewFF1 returns an FF1 instance keyed with a SEA-Lion key over the given
+// alphabet, whose length is the radix.
+func NewFF1(key []byte, alphabet string) (*FF1, error) {
+	b, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return newFF1(b, alphabet)
+}
+
+func newFF1(b cipher.Block, alphabet string) (*FF1, error) {
+	a, err := newFPEAlphabet(alphabet)
+	if err != nil {
+		return nil, err
+	}
+	return &FF1{b: b, alphabet: a}, nil
+}
+
+// Encrypt encrypts plaintext under tweak, which may be of any length
+// including zero.
+func (f *FF1) Encrypt(plaintext string, tweak []byte) (string, error) {
+	return f.crypt(plaintext, tweak, false)
+}
+
+// Decrypt decrypts ciphertext under tweak.
+func (f *FF1) Decrypt(ciphertext string, tweak []byte) (string, error) {
+	return f.crypt(ciphertext, tweak, true)
+}
+
+func (f *FF1) crypt(in string, tweak []byte, decrypt bool) (string, error) {
+	x, err := f.alphabet.numerals(in)
+	if err != nil {
+		return "", err
+	}
+	if len(x) < f.alphabet.minLength() || uint64(len(x)) > ff1MaxLength {
+		return "", errFPELength
+	}
+	if uint64(len(tweak)) > math.MaxUint32 {
+		return "", errFPETweak
+	}
+
+	radix := f.alphabet.radix()
+	n, t := len(x), len(tweak)
+	u := n / 2
+	v := n - u
+	a, bb := x[:u], x[u:]
+
+	// b = ceil(ceil(v * log2(radix)) / 8), d = 4 * ceil(b / 4) + 4
+	b := (fpeModulus(radix, v).Sub(fpeModulus(radix, v), big.NewInt(1)).BitLen() + 7) / 8
+	d := 4*((b+3)/4) + 4
+
+	// P = [1]^1 || [2]^1 || [1]^1 || [radix]^3 || [10]^1 || [u mod 256]^1 || [n]^4 || [t]^4
+	var p [BlockSize]byte
+	p[0], p[1], p[2] = 1, 2, 1
+	p[3], p[4], p[5] = byte(radix>>16), byte(radix>>8), byte(radix)
+	p[6] = ff1Rounds
+	p[7] = byte(u)
+	binary.BigEndian.PutUint32(p[8:12], uint32(n))
+	binary.BigEndian.PutUint32(p[12:16], uint32(t))
+
+	// Q = T || [0]^((-t-b-1) mod 16) || [i]^1 || [NUM_radix(B)]^b
+	q := make([]byte, t+(((-t-b-1)%16)+16)%16+1+b)
+	copy(q, tweak)
+
+	// y = NUM(S) for round i, keyed on the numerals of the unchanged half
+	roundValue := func(i int, half []int) *big.Int {
+		q[len(q)-b-1] = byte(i)
+		num(half, radix).FillBytes(q[len(q)-b:])
+		return new(big.Int).SetBytes(f.keystream(&p, q, d))
+	}
+
+	modU, modV := fpeModulus(radix, u), fpeModulus(radix, v)
+	if !decrypt {
+		for i := 0; i < ff1Rounds; i++ {
+			m, mod := u, modU
+			if i%2 == 1 {
+				m, mod = v, modV
+			}
+			// c = (NUM_radix(A) + y) mod radix^m, A = B, B = STR^m_radix(c)
+			c := num(a, radix)
+			c.Add(c, roundValue(i, bb)).Mod(c, mod)
+			a, bb = bb, str(c, radix, m)
+		}
+	} else {
+		for i := ff1Rounds - 1; i >= 0; i-- {
+			m, mod := u, modU
+			if i%2 == 1 {
+				m, mod = v, modV
+			}
+			// c = (NUM_radix(B) - y) mod radix^m, B = A, A = STR^m_radix(c)
+			c := num(bb, radix)
+			c.Sub(c, roundValue(i, a)).Mod(c, mod)
+			a, bb = str(c, radix, m), a
+		}
+	}
+
+	return f.alphabet.format(append(append([]int(nil), a...), bb...)), nil
+}
+
+// keystream computes S, the first d bytes of R || CIPH(R xor [1]) ||
+// CIPH(R xor [2]) || ..., where R = PRF(P || Q) is a CBC-MAC.
+func (f *FF1) keystream(p *[BlockSize]byte, q []byte, d int) []byte {
+	var r [BlockSize]byte
+	f.b.Encrypt(r[:], p[:])
+	for len(q) > 0 {
+		subtle.XORBytes(r[:], r[:], q[:BlockSize])
+		f.b.Encrypt(r[:], r[:])
+		q = q[BlockSize:]
+	}
+
+	s := make([]byte, 0, (d+BlockSize-1)/BlockSize*BlockSize)
+	s = append(s, r[:]...)
+	for j := 1; len(s) < d; j++ {
+		var block [BlockSize]byte
+		binary.BigEndian.PutUint64(block[8:], uint64(j))
+		subtle.XORBytes(block[:], block[:], r[:])
+		f.b.Encrypt(block[:], block[:])
+		s = append(s, block[:]...)
+	}
+	return s[:d]
+}
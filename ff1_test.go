@@ -0,0 +1,87 @@
+package sealion
+
+import (
+	"crypto/aes"
+	"testing"
+)
+
+// NIST SP 800-38G FF1 samples 1-3 and 9, with AES in place of SEA-Lion
+var ff1AESTests = []struct {
+	key, alphabet, tweak, plaintext, ciphertext string
+}{
+	{"2B7E151628AED2A6ABF7158809CF4F3C", "0123456789", "", "0123456789", "2433477484"},
+	{"2B7E151628AED2A6ABF7158809CF4F3C", "0123456789", "39383736353433323130", "0123456789", "6124200773"},
+	{"2B7E151628AED2A6ABF7158809CF4F3C", "0123456789abcdefghijklmnopqrstuvwxyz", "3737373770717273373737", "0123456789abcdefghi", "a9tv40mll9kdu509eum"},
+	{"2B7E151628AED2A6ABF7158809CF4F3CEF4359D8D580AA4F7F036D6F04FC6A94", "0123456789abcdefghijklmnopqrstuvwxyz", "3737373770717273373737", "0123456789abcdefghi", "xs8a0azh2avyalyzuwd"},
+}
+
+func TestFF1AES(t *testing.T) {
+	for i, tt := range ff1AESTests {
+		b, _ := aes.NewCipher(fromHex(tt.key))
+		f, err := newFF1(b, tt.alphabet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct, err := f.Encrypt(tt.plaintext, fromHex(tt.tweak))
+		if err != nil || ct != tt.ciphertext {
+			t.Errorf("#%d: got %q, %v, want %q", i, ct, err, tt.ciphertext)
+			continue
+		}
+		pt, err := f.Decrypt(ct, fromHex(tt.tweak))
+		if err != nil || pt != tt.plaintext {
+			t.Errorf("#%d: decrypted %q, %v", i, pt, err)
+		}
+	}
+}
+
+func TestFF1(t *testing.T) {
+	f, err := NewFF1(make([]byte, 16), "0123456789")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testFPE(t, f.Encrypt, f.Decrypt, []byte("tweak"))
+
+	if _, err := NewFF1(make([]byte, 16), "0"); err == nil {
+		t.Error("accepted a one-character alphabet")
+	}
+	if _, err := NewFF1(make([]byte, 16), "0120"); err == nil {
+		t.Error("accepted an alphabet with a repeated character")
+	}
+}
+
+// testFPE checks length and format preservation, tweak separation and input
+// validation of a decimal format-preserving cipher.
+func testFPE(t *testing.T, encrypt, decrypt func(string, []byte) (string, error), tweak []byte) {
+	t.Helper()
+	for _, plaintext := range []string{"000000", "4111111111111111", "1234567890123456789"} {
+		ct, err := encrypt(plaintext, tweak)
+		if err != nil {
+			t.Fatalf("%q: %v", plaintext, err)
+		}
+		if len(ct) != len(plaintext) || ct == plaintext {
+			t.Errorf("%q: encrypted to %q", plaintext, ct)
+		}
+		for _, r := range ct {
+			if r < '0' || r > '9' {
+				t.Errorf("%q: ciphertext %q leaves the alphabet", plaintext, ct)
+			}
+		}
+		pt, err := decrypt(ct, tweak)
+		if err != nil || pt != plaintext {
+			t.Errorf("%q: decrypted to %q, %v", plaintext, pt, err)
+		}
+
+		other := append([]byte(nil), tweak...)
+		other[0] ^= 1
+		if ct2, _ := encrypt(plaintext, other); ct2 == ct {
+			t.Errorf("%q: tweak has no effect", plaintext)
+		}
+	}
+
+	if _, err := encrypt("12345", tweak); err == nil {
+		t.Error("accepted an input below the minimum domain size")
+	}
+	if _, err := encrypt("12345a", tweak); err == nil {
+		t.Error("accepted a character outside the alphabet")
+	}
+}
@@ -0,0 +1,112 @@
+package sealion
+
+import (
+	"errors"
+	"math/big"
+)
+
+const (
+	fpeMinRadix = 2
+	fpeMaxRadix = 1 << 16
+	// SP 800-38G Rev. 1 requires radix^minlen >= 1,000,000
+	fpeMinDomain = 1000000
+)
+
+var (
+	errFPEAlphabet = errors.New("sealion: FPE alphabet must hold 2 to 65536 distinct characters")
+	errFPELength   = errors.New("sealion: FPE input length out of range")
+	errFPESymbol   = errors.New("sealion: FPE input contains characters outside the alphabet")
+	errFPETweak    = errors.New("sealion: invalid FPE tweak length")
+)
+
+// fpeAlphabet maps between the characters of a format-preserving encryption
+// domain and their numeral values; its radix is the number of characters.
+type fpeAlphabet struct {
+	symbols []rune
+	index   map[rune]int
+}
+
+func newFPEAlphabet(alphabet string) (*fpeAlphabet, error) {
+	a := &fpeAlphabet{symbols: []rune(alphabet), index: make(map[rune]int)}
+	if len(a.symbols) < fpeMinRadix || len(a.symbols) > fpeMaxRadix {
+		return nil, errFPEAlphabet
+	}
+	for i, r := range a.symbols {
+		if _, ok := a.index[r]; ok {
+			return nil, errFPEAlphabet
+		}
+		a.index[r] = i
+	}
+	return a, nil
+}
+
+func (a *fpeAlphabet) radix() int {
+	return len(a.symbols)
+}
+
+// minLength returns the shortest input length whose domain holds at least
+// fpeMinDomain values.
+func (a *fpeAlphabet) minLength() int {
+	n := 1
+	for domain := a.radix(); domain < fpeMinDomain; domain *= a.radix() {
+		n++
+	}
+	return n
+}
+
+func (a *fpeAlphabet) numerals(s string) ([]int, error) {
+	x := make([]int, 0, len(s))
+	for _, r := range s {
+		i, ok := a.index[r]
+		if !ok {
+			return nil, errFPESymbol
+		}
+		x = append(x, i)
+	}
+	return x, nil
+}
+
+func (a *fpeAlphabet) format(x []int) string {
+	s := make([]rune, len(x))
+	for i, n := range x {
+		s[i] = a.symbols[n]
+	}
+	return string(s)
+}
+
+// num returns NUM_radix(x), the number with most significant numeral first.
+func num(x []int, radix int) *big.Int {
+	r := big.NewInt(int64(radix))
+	n := new(big.Int)
+	for _, d := range x {
+		n.Mul(n, r)
+		n.Add(n, big.NewInt(int64(d)))
+	}
+	return n
+}
+
+// str returns STR^m_radix(n), the m numerals representing n mod radix^m.
+func str(n *big.Int, radix, m int) []int {
+	x := make([]int, m)
+	r := big.NewInt(int64(radix))
+	n = new(big.Int).Set(n)
+	d := new(big.Int)
+	for i := m - 1; i >= 0; i-- {
+		n.DivMod(n, r, d)
+		x[i] = int(d.Int64())
+	}
+	return x
+}
+
+func reverseNumerals(x []int) []int {
+	y := make([]int, len(x))
+	for i, d := range x {
+		y[len(x)-1-i] = d
+	}
+	return y
+}
+
+// fpeModulus returns radix^m.
+func fpeModulus(radix, m int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(int64(radix)), big.NewInt(int64(m)), nil)
+}
@@ -0,0 +1,157 @@
+package sealion
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+)
+
+const (
+	gcmSIVNonceSize = 12
+	gcmSIVTagSize   = 16
+	// Plaintext and associated data are limited to 2^36 bytes
+	gcmSIVMaxLength = 1 << 36
+)
+
+type gcmSIV struct {
+	kgk      cipher.Block
+	keySize  int
+	newBlock func(key []byte) (cipher.Block, error)
+}
+
+// NewGCMSIV returns the nonce-misuse-resistant GCM-SIV mode of RFC 8452 with
+// SEA-Lion in place of AES. key is the key-generating key, from which fresh
+// message authentication and encryption keys are derived for every nonce.
+func NewGCMSIV(key []byte) (cipher.AEAD, error) {
+	switch len(key) {
+	case 16, 24, 32:
+		break
+	default:
+		return nil, KeySizeError(len(key))
+	}
+	return newGCMSIV(key, NewCipher)
+}
+
+func newGCMSIV(key []byte, newBlock func([]byte) (cipher.Block, error)) (*gcmSIV, error) {
+	kgk, err := newBlock(key)
+	if err != nil {
+		return nil, err
+	}
+	return &gcmSIV{kgk: kgk, keySize: len(key), newBlock: newBlock}, nil
+}
+
+func (g *gcmSIV) NonceSize() int {
+	return gcmSIVNonceSize
+}
+
+func (g *gcmSIV) Overhead() int {
+	return gcmSIVTagSize
+}
+
+func (g *gcmSIV) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != gcmSIVNonceSize {
+		panic("sealion: incorrect nonce length given to GCM-SIV")
+	}
+	if uint64(len(plaintext)) > gcmSIVMaxLength || uint64(len(additionalData)) > gcmSIVMaxLength {
+		panic("sealion: message too large for GCM-SIV")
+	}
+
+	ret, out := sliceForAppend(dst, len(plaintext)+gcmSIVTagSize)
+	if inexactOverlap(out, plaintext) {
+		panic("sealion: invalid buffer overlap")
+	}
+
+	authKey, enc := g.deriveKeys(nonce)
+	tag := g.tag(authKey, enc, nonce, plaintext, additionalData)
+	g.ctr(enc, &tag, out, plaintext)
+	copy(out[len(plaintext):], tag[:])
+	return ret
+}
+
+func (g *gcmSIV) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != gcmSIVNonceSize {
+		panic("sealion: incorrect nonce length given to GCM-SIV")
+	}
+	if len(ciphertext) < gcmSIVTagSize ||
+		uint64(len(ciphertext)) > gcmSIVMaxLength+gcmSIVTagSize ||
+		uint64(len(additionalData)) > gcmSIVMaxLength {
+		return nil, errOpen
+	}
+
+	var tag [gcmSIVTagSize]byte
+	copy(tag[:], ciphertext[len(ciphertext)-gcmSIVTagSize:])
+	ciphertext = ciphertext[:len(ciphertext)-gcmSIVTagSize]
+
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	if inexactOverlap(out, ciphertext) {
+		panic("sealion: invalid buffer overlap")
+	}
+
+	authKey, enc := g.deriveKeys(nonce)
+	g.ctr(enc, &tag, out, ciphertext)
+
+	expected := g.tag(authKey, enc, nonce, out, additionalData)
+	if subtle.ConstantTimeCompare(expected[:], tag[:]) != 1 {
+		for i := range out {
+			out[i] = 0
+		}
+		return nil, errOpen
+	}
+	return ret, nil
+}
+
+// deriveKeys returns the per-nonce message authentication key and a block
+// cipher under the per-nonce message encryption key. Each key is built from
+// the first half of successive encryptions of le32(counter) || nonce.
+func (g *gcmSIV) deriveKeys(nonce []byte) ([]byte, cipher.Block) {
+	derived := make([]byte, 0, 16+g.keySize)
+	var in, out [BlockSize]byte
+	copy(in[4:], nonce)
+	for i := 0; i < cap(derived)/8; i++ {
+		binary.LittleEndian.PutUint32(in[:4], uint32(i))
+		g.kgk.Encrypt(out[:], in[:])
+		derived = append(derived, out[:8]...)
+	}
+
+	enc, err := g.newBlock(derived[16:])
+	if err != nil {
+		panic(err)
+	}
+	return derived[:16], enc
+}
+
+// tag computes the encrypted POLYVAL of the associated data, the plaintext
+// and their bit lengths, with the nonce mixed in.
+func (g *gcmSIV) tag(authKey []byte, enc cipher.Block, nonce, plaintext, additionalData []byte) [BlockSize]byte {
+	var lengths [BlockSize]byte
+	binary.LittleEndian.PutUint64(lengths[:8], uint64(len(additionalData))*8)
+	binary.LittleEndian.PutUint64(lengths[8:], uint64(len(plaintext))*8)
+
+	p := newPolyval(authKey)
+	p.update(additionalData)
+	p.update(plaintext)
+	p.update(lengths[:])
+
+	s := p.sum()
+	subtle.XORBytes(s[:], s[:], nonce)
+	s[BlockSize-1] &= 0x7f
+	enc.Encrypt(s[:], s[:])
+	return s
+}
+
+// ctr applies the GCM-SIV keystream, whose initial counter block is the tag
+// with its top bit set and whose first 32 bits are a little-endian counter.
+func (g *gcmSIV) ctr(enc cipher.Block, tag *[BlockSize]byte, dst, src []byte) {
+	counter := *tag
+	counter[BlockSize-1] |= 0x80
+	c := binary.LittleEndian.Uint32(counter[:4])
+
+	var keystream [BlockSize]byte
+	for len(src) > 0 {
+		binary.LittleEndian.PutUint32(counter[:4], c)
+		enc.Encrypt(keystream[:], counter[:])
+		n := subtle.XORBytes(dst, src, keystream[:])
+		dst, src = dst[n:], src[n:]
+		c++
+	}
+}
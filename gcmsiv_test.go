@@ -0,0 +1,51 @@
+package sealion
+
+import (
+	"bytes"
+	"crypto/aes"
+	"testing"
+)
+
+// RFC 8452, Appendix C, with AES in place of SEA-Lion
+var gcmSIVAESTests = []struct {
+	key, nonce, ad, plaintext, ciphertext string
+}{
+	{"01000000000000000000000000000000", "030000000000000000000000", "", "", "dc20e2d83f25705bb49e439eca56de25"},
+	{"01000000000000000000000000000000", "030000000000000000000000", "", "0100000000000000", "b5d839330ac7b786578782fff6013b815b287c22493a364c"},
+	{"01000000000000000000000000000000", "030000000000000000000000", "", "01000000000000000000000000000000", "743f7c8077ab25f8624e2e948579cf77303aaf90f6fe21199c6068577437a0c4"},
+	{"01000000000000000000000000000000", "030000000000000000000000", "01", "0200000000000000", "1e6daba35669f4273b0a1a2560969cdf790d99759abd1508"},
+	{"0100000000000000000000000000000000000000000000000000000000000000", "030000000000000000000000", "", "0100000000000000", "c2ef328e5c71c83b843122130f7364b761e0b97427e3df28"},
+}
+
+func TestGCMSIVAES(t *testing.T) {
+	for i, tt := range gcmSIVAESTests {
+		g, err := newGCMSIV(fromHex(tt.key), aes.NewCipher)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkAEAD(t, i, g, tt.nonce, tt.ad, tt.plaintext, tt.ciphertext)
+	}
+}
+
+func TestGCMSIV(t *testing.T) {
+	for _, size := range []int{16, 24, 32} {
+		g, err := NewGCMSIV(make([]byte, size))
+		if err != nil {
+			t.Fatal(err)
+		}
+		testAEAD(t, g)
+	}
+	if _, err := NewGCMSIV(make([]byte, 20)); err == nil {
+		t.Error("accepted a 20-byte key")
+	}
+}
+
+// RFC 8452, Appendix A
+func TestPolyval(t *testing.T) {
+	p := newPolyval(fromHex("25629347589242761d31f826ba4b757b"))
+	p.update(fromHex("4f4f95668c83dfb6401762bb2d01a262d1a24ddd2721d006bbe45f20d3c9f362"))
+	got, want := p.sum(), fromHex("f7a3b47b846119fae5b7866cf5e5b77e")
+	if !bytes.Equal(got[:], want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+}
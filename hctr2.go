@@ -0,0 +1,124 @@
+package sealion
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+)
+
+// HCTR2 implements the HCTR2 length-preserving, tweakable wide-block
+// encryption mode with SEA-Lion in place of AES. Every bit of the output
+// depends on every bit of the input and of the tweak.
+type HCTR2 struct {
+	b cipher.Block
+	h [BlockSize]byte // hash key, E_K(bin(0))
+	l [BlockSize]byte // E_K(bin(1))
+}
+
+// NewHCTR2 returns an HCTR2 instance keyed with a SEA-Lion key.
+func NewHCTR2(key []byte) (*HCTR2, error) {
+	b, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return newHCTR2(b), nil
+}
+
+func newHCTR2(b cipher.Block) *HCTR2 {
+	h := &HCTR2{b: b}
+	b.Encrypt(h.h[:], h.h[:])
+	h.l[0] = 1
+	b.Encrypt(h.l[:], h.l[:])
+	return h
+}
+
+// Encrypt encrypts src, which must be at least one block long, into dst under
+// tweak. dst and src must overlap entirely or not at all.
+func (h *HCTR2) Encrypt(dst, src, tweak []byte) {
+	h.crypt(dst, src, tweak, false)
+}
+
+// Decrypt decrypts src, which must be at least one block long, into dst under
+// tweak. dst and src must overlap entirely or not at all.
+func (h *HCTR2) Decrypt(dst, src, tweak []byte) {
+	h.crypt(dst, src, tweak, true)
+}
+
+func (h *HCTR2) crypt(dst, src, tweak []byte, decrypt bool) {
+	if len(src) < BlockSize {
+		panic("sealion: input not full block")
+	}
+	if len(dst) < len(src) {
+		panic("sealion: output smaller than input")
+	}
+	dst = dst[:len(src)]
+	if inexactOverlap(dst, src) {
+		panic("sealion: invalid buffer overlap")
+	}
+
+	// The tweak is hashed the same way for both halves of the construction
+	th := h.hashTweak(tweak, len(src)-BlockSize)
+
+	// Encryption maps M || N to U || V, decryption U || V to M || N
+	var mm, uu, s [BlockSize]byte
+	hash := h.hashTail(th, src[BlockSize:])
+	subtle.XORBytes(mm[:], src[:BlockSize], hash[:])
+	if decrypt {
+		h.b.Decrypt(uu[:], mm[:])
+	} else {
+		h.b.Encrypt(uu[:], mm[:])
+	}
+
+	// S = MM xor UU xor L
+	subtle.XORBytes(s[:], mm[:], uu[:])
+	subtle.XORBytes(s[:], s[:], h.l[:])
+	h.xctr(dst[BlockSize:], src[BlockSize:], &s)
+
+	hash = h.hashTail(th, dst[BlockSize:])
+	subtle.XORBytes(dst[:BlockSize], uu[:], hash[:])
+}
+
+// hashTweak returns the POLYVAL state after absorbing the tweak length block
+// and the zero padded tweak.
+func (h *HCTR2) hashTweak(tweak []byte, tailLength int) polyval {
+	var lengthBlock [BlockSize]byte
+	tweakLength := uint64(len(tweak)) * 8 * 2
+	if tailLength%BlockSize == 0 {
+		tweakLength += 2
+	} else {
+		tweakLength += 3
+	}
+	binary.LittleEndian.PutUint64(lengthBlock[:8], tweakLength)
+
+	p := newPolyval(h.h[:])
+	p.update(lengthBlock[:])
+	p.update(tweak)
+	return *p
+}
+
+// hashTail finishes H(T, N) from the tweak state th, padding a partial final
+// block of n with a single one byte followed by zeros.
+func (h *HCTR2) hashTail(th polyval, n []byte) [BlockSize]byte {
+	full := len(n) / BlockSize * BlockSize
+	th.update(n[:full])
+	if full < len(n) {
+		var last [BlockSize]byte
+		copy(last[:], n[full:])
+		last[len(n)-full] = 1
+		th.update(last[:])
+	}
+	return th.sum()
+}
+
+// xctr applies the XCTR keystream, whose blocks are E_K(S xor bin(i)) for
+// i = 1, 2, ...
+func (h *HCTR2) xctr(dst, src []byte, s *[BlockSize]byte) {
+	var counter, keystream [BlockSize]byte
+	for i := uint64(1); len(src) > 0; i++ {
+		counter = *s
+		binary.LittleEndian.PutUint64(counter[:8], binary.LittleEndian.Uint64(s[:8])^i)
+		h.b.Encrypt(keystream[:], counter[:])
+		n := subtle.XORBytes(dst, src, keystream[:])
+		dst, src = dst[n:], src[n:]
+	}
+}
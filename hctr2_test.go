@@ -0,0 +1,94 @@
+package sealion
+
+import (
+	"bytes"
+	"testing"
+)
+
+// There are no published HCTR2 vectors for SEA-Lion; these were produced by
+// this implementation and pin its output. The key is 00 01 ... 1f, the tweak
+// "tweak" and the plaintext 00 01 02 ... of the ciphertext's length.
+var hctr2Tests = []string{
+	"b20f39c3ff3764df10fb02c8f4f0abc0",
+	"eb0b6b8d84ef17b21cf56f555f0fe4a177",
+	"c0a9207d776a4407cb018494ff8fbc9e72aea755a85206d71f9ea68f2848afa549bf15b157722d120d1568ca058759b5",
+}
+
+func TestHCTR2Vectors(t *testing.T) {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	h, err := NewHCTR2(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, ciphertext := range hctr2Tests {
+		want := fromHex(ciphertext)
+		plaintext := make([]byte, len(want))
+		for j := range plaintext {
+			plaintext[j] = byte(j)
+		}
+		got := make([]byte, len(plaintext))
+		h.Encrypt(got, plaintext, []byte("tweak"))
+		if !bytes.Equal(got, want) {
+			t.Errorf("#%d: got %x, want %x", i, got, want)
+		}
+	}
+}
+
+func TestHCTR2(t *testing.T) {
+	h, err := NewHCTR2(make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, n := range []int{16, 17, 31, 32, 33, 100, 4096} {
+		plaintext := make([]byte, n)
+		for i := range plaintext {
+			plaintext[i] = byte(i)
+		}
+		ct := make([]byte, n)
+		h.Encrypt(ct, plaintext, []byte("tweak"))
+
+		pt := make([]byte, n)
+		h.Decrypt(pt, ct, []byte("tweak"))
+		if !bytes.Equal(pt, plaintext) {
+			t.Fatalf("length %d: round trip failed", n)
+		}
+
+		buf := append([]byte(nil), plaintext...)
+		h.Encrypt(buf, buf, []byte("tweak"))
+		if !bytes.Equal(buf, ct) {
+			t.Fatalf("length %d: in-place encryption differs", n)
+		}
+
+		// A change anywhere must change the first and last blocks
+		for _, pos := range []int{0, n - 1} {
+			changed := append([]byte(nil), plaintext...)
+			changed[pos] ^= 1
+			out := make([]byte, n)
+			h.Encrypt(out, changed, []byte("tweak"))
+			if bytes.Equal(out[:BlockSize], ct[:BlockSize]) || bytes.Equal(out[n-BlockSize:], ct[n-BlockSize:]) {
+				t.Errorf("length %d: flipping byte %d did not diffuse", n, pos)
+			}
+		}
+
+		out := make([]byte, n)
+		h.Encrypt(out, plaintext, []byte("other"))
+		if bytes.Equal(out, ct) {
+			t.Errorf("length %d: tweak has no effect", n)
+		}
+	}
+}
+
+func TestHCTR2ShortInput(t *testing.T) {
+	h, _ := NewHCTR2(make([]byte, 16))
+	defer func() {
+		if recover() == nil {
+			t.Error("encrypted an input shorter than a block")
+		}
+	}()
+	h.Encrypt(make([]byte, 15), make([]byte, 15), nil)
+}
@@ -0,0 +1,377 @@
+// Package jwe implements JSON Web Encryption (RFC 7516) in compact
+// serialization with SEA-Lion based algorithms:
+//
+//	alg  SL128KW, SL192KW, SL256KW  RFC 3394 key wrap with SEA-Lion
+//	     dir                        the key is used directly as the CEK
+//	enc  SL128GCM, SL192GCM, SL256GCM
+//	     SL128CBC-HS256, SL192CBC-HS384, SL256CBC-HS512
+//
+// The GCM and CBC-HMAC content encryptions follow RFC 7518 sections 5.3 and
+// 5.2 with SEA-Lion in place of AES.
+package jwe
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+
+	"github.com/Sid-Sun/sealion"
+)
+
+// Key management algorithms
+const (
+	SL128KW = "SL128KW"
+	SL192KW = "SL192KW"
+	SL256KW = "SL256KW"
+	Direct  = "dir"
+)
+
+// Content encryption algorithms
+const (
+	SL128GCM      = "SL128GCM"
+	SL192GCM      = "SL192GCM"
+	SL256GCM      = "SL256GCM"
+	SL128CBCHS256 = "SL128CBC-HS256"
+	SL192CBCHS384 = "SL192CBC-HS384"
+	SL256CBCHS512 = "SL256CBC-HS512"
+)
+
+var (
+	// ErrMalformed is returned for tokens that are not valid compact JWEs.
+	ErrMalformed = errors.New("jwe: malformed token")
+	// ErrDecrypt is returned when the content encryption key cannot be
+	// unwrapped or the content fails authentication.
+	ErrDecrypt = errors.New("jwe: decryption failed")
+
+	errKeySize = errors.New("jwe: key size does not match algorithm")
+)
+
+// Header is the JOSE protected header. Alg and Enc are required; the other
+// fields are optional and carried as is.
+type Header struct {
+	Alg string `json:"alg"`
+	Enc string `json:"enc"`
+	Kid string `json:"kid,omitempty"`
+	Typ string `json:"typ,omitempty"`
+	Cty string `json:"cty,omitempty"`
+}
+
+// Encrypt encrypts plaintext for key as described by header and returns the
+// compact serialization. For the key wrap algorithms key is the
+// key-encryption key; for "dir" it is the content encryption key itself.
+func Encrypt(plaintext, key []byte, header Header) (string, error) {
+	kw, ok := algorithms[header.Alg]
+	if !ok {
+		return "", fmt.Errorf("jwe: unsupported alg %q", header.Alg)
+	}
+	ce, ok := encryptions[header.Enc]
+	if !ok {
+		return "", fmt.Errorf("jwe: unsupported enc %q", header.Enc)
+	}
+
+	var cek, encryptedKey []byte
+	if kw == 0 {
+		if len(key) != ce.keySize {
+			return "", errKeySize
+		}
+		cek = key
+	} else {
+		if len(key) != kw {
+			return "", errKeySize
+		}
+		cek = make([]byte, ce.keySize)
+		if _, err := rand.Read(cek); err != nil {
+			return "", err
+		}
+		var err error
+		if encryptedKey, err = sealion.Wrap(key, cek); err != nil {
+			return "", err
+		}
+	}
+
+	rawHeader, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	protected := base64.RawURLEncoding.EncodeToString(rawHeader)
+
+	iv := make([]byte, ce.ivSize)
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+	ciphertext, tag, err := ce.seal(cek, iv, plaintext, []byte(protected))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join([]string{
+		protected,
+		base64.RawURLEncoding.EncodeToString(encryptedKey),
+		base64.RawURLEncoding.EncodeToString(iv),
+		base64.RawURLEncoding.EncodeToString(ciphertext),
+		base64.RawURLEncoding.EncodeToString(tag),
+	}, "."), nil
+}
+
+// Decrypt parses and validates a compact JWE and decrypts it with key. The
+// header must name supported algorithms and must not use compression or
+// critical extensions, and key must match the size alg requires.
+func Decrypt(token string, key []byte) ([]byte, *Header, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 5 {
+		return nil, nil, ErrMalformed
+	}
+	var raw [5][]byte
+	for i, part := range parts {
+		b, err := base64.RawURLEncoding.DecodeString(part)
+		if err != nil {
+			return nil, nil, ErrMalformed
+		}
+		raw[i] = b
+	}
+	encryptedKey, iv, ciphertext, tag := raw[1], raw[2], raw[3], raw[4]
+
+	header, err := parseHeader(raw[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	kw := algorithms[header.Alg]
+	ce := encryptions[header.Enc]
+	if len(iv) != ce.ivSize || len(tag) != ce.tagSize {
+		return nil, nil, ErrMalformed
+	}
+
+	var cek []byte
+	if kw == 0 {
+		if len(encryptedKey) != 0 {
+			return nil, nil, ErrMalformed
+		}
+		if len(key) != ce.keySize {
+			return nil, nil, errKeySize
+		}
+		cek = key
+	} else {
+		if len(key) != kw {
+			return nil, nil, errKeySize
+		}
+		if cek, err = sealion.Unwrap(key, encryptedKey); err != nil {
+			return nil, nil, ErrDecrypt
+		}
+		if len(cek) != ce.keySize {
+			return nil, nil, ErrDecrypt
+		}
+	}
+
+	plaintext, err := ce.open(cek, iv, ciphertext, tag, []byte(parts[0]))
+	if err != nil {
+		return nil, nil, ErrDecrypt
+	}
+	return plaintext, header, nil
+}
+
+// parseHeader decodes the protected header. Member names are matched
+// exactly, as RFC 7515 requires, so duplicate members and case variants of
+// the registered names are rejected rather than resolved by encoding/json.
+func parseHeader(raw []byte) (*Header, error) {
+	members, err := headerMembers(raw)
+	if err != nil {
+		return nil, err
+	}
+	for name := range members {
+		for _, known := range headerNames {
+			if name != known && strings.EqualFold(name, known) {
+				return nil, fmt.Errorf("jwe: invalid header member %q", name)
+			}
+		}
+	}
+	if _, ok := members["zip"]; ok {
+		return nil, errors.New("jwe: compression is not supported")
+	}
+	if _, ok := members["crit"]; ok {
+		return nil, errors.New("jwe: critical header parameters are not supported")
+	}
+
+	var h Header
+	for name, field := range map[string]*string{"alg": &h.Alg, "enc": &h.Enc, "kid": &h.Kid, "typ": &h.Typ, "cty": &h.Cty} {
+		if v, ok := members[name]; ok {
+			if err := json.Unmarshal(v, field); err != nil {
+				return nil, ErrMalformed
+			}
+		}
+	}
+	if _, ok := algorithms[h.Alg]; !ok {
+		return nil, fmt.Errorf("jwe: unsupported alg %q", h.Alg)
+	}
+	if _, ok := encryptions[h.Enc]; !ok {
+		return nil, fmt.Errorf("jwe: unsupported enc %q", h.Enc)
+	}
+	return &h, nil
+}
+
+// headerNames are the member names parseHeader interprets.
+var headerNames = []string{"alg", "enc", "kid", "typ", "cty", "zip", "crit"}
+
+// headerMembers splits a JSON object into its members, rejecting duplicates.
+func headerMembers(raw []byte) (map[string]json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, ErrMalformed
+	}
+	members := make(map[string]json.RawMessage)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, ErrMalformed
+		}
+		name := tok.(string)
+		if _, ok := members[name]; ok {
+			return nil, fmt.Errorf("jwe: duplicate header member %q", name)
+		}
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			return nil, ErrMalformed
+		}
+		members[name] = v
+	}
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('}') {
+		return nil, ErrMalformed
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, ErrMalformed
+	}
+	return members, nil
+}
+
+// algorithms maps each alg to its key-encryption key size; 0 means "dir".
+var algorithms = map[string]int{
+	SL128KW: 16,
+	SL192KW: 24,
+	SL256KW: 32,
+	Direct:  0,
+}
+
+var encryptions = map[string]*contentEncryption{
+	SL128GCM:      newGCM(16, sealion.NewCipher),
+	SL192GCM:      newGCM(24, sealion.NewCipher),
+	SL256GCM:      newGCM(32, sealion.NewCipher),
+	SL128CBCHS256: newCBCHMAC(16, sha256.New, sealion.NewCipher),
+	SL192CBCHS384: newCBCHMAC(24, sha512.New384, sealion.NewCipher),
+	SL256CBCHS512: newCBCHMAC(32, sha512.New, sealion.NewCipher),
+}
+
+type contentEncryption struct {
+	keySize, ivSize, tagSize int
+	seal                     func(cek, iv, plaintext, aad []byte) (ciphertext, tag []byte, err error)
+	open                     func(cek, iv, ciphertext, tag, aad []byte) ([]byte, error)
+}
+
+func newGCM(keySize int, newBlock func([]byte) (cipher.Block, error)) *contentEncryption {
+	return &contentEncryption{
+		keySize: keySize,
+		ivSize:  12,
+		tagSize: 16,
+		seal: func(cek, iv, plaintext, aad []byte) ([]byte, []byte, error) {
+			aead, err := gcm(newBlock, cek)
+			if err != nil {
+				return nil, nil, err
+			}
+			out := aead.Seal(nil, iv, plaintext, aad)
+			n := len(out) - aead.Overhead()
+			return out[:n], out[n:], nil
+		},
+		open: func(cek, iv, ciphertext, tag, aad []byte) ([]byte, error) {
+			aead, err := gcm(newBlock, cek)
+			if err != nil {
+				return nil, err
+			}
+			in := make([]byte, 0, len(ciphertext)+len(tag))
+			in = append(in, ciphertext...)
+			in = append(in, tag...)
+			return aead.Open(nil, iv, in, aad)
+		},
+	}
+}
+
+func gcm(newBlock func([]byte) (cipher.Block, error), key []byte) (cipher.AEAD, error) {
+	b, err := newBlock(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(b)
+}
+
+// newCBCHMAC returns the composite CBC-HMAC encryption of RFC 7518 section
+// 5.2: the CEK is MAC_KEY || ENC_KEY, the content is CBC encrypted with PKCS
+// #7 padding and the tag is the first half of
+// HMAC(MAC_KEY, AAD || IV || ciphertext || AL), AL being the AAD length in
+// bits as a 64-bit big-endian integer.
+func newCBCHMAC(keySize int, newHash func() hash.Hash, newBlock func([]byte) (cipher.Block, error)) *contentEncryption {
+	tag := func(macKey, iv, ciphertext, aad []byte) []byte {
+		mac := hmac.New(newHash, macKey)
+		mac.Write(aad)
+		mac.Write(iv)
+		mac.Write(ciphertext)
+		var al [8]byte
+		binary.BigEndian.PutUint64(al[:], uint64(len(aad))*8)
+		mac.Write(al[:])
+		return mac.Sum(nil)[:keySize]
+	}
+
+	return &contentEncryption{
+		keySize: 2 * keySize,
+		ivSize:  sealion.BlockSize,
+		tagSize: keySize,
+		seal: func(cek, iv, plaintext, aad []byte) ([]byte, []byte, error) {
+			b, err := newBlock(cek[keySize:])
+			if err != nil {
+				return nil, nil, err
+			}
+			pad := sealion.BlockSize - len(plaintext)%sealion.BlockSize
+			ciphertext := make([]byte, len(plaintext)+pad)
+			copy(ciphertext, plaintext)
+			for i := len(plaintext); i < len(ciphertext); i++ {
+				ciphertext[i] = byte(pad)
+			}
+			cipher.NewCBCEncrypter(b, iv).CryptBlocks(ciphertext, ciphertext)
+			return ciphertext, tag(cek[:keySize], iv, ciphertext, aad), nil
+		},
+		open: func(cek, iv, ciphertext, expected, aad []byte) ([]byte, error) {
+			if subtle.ConstantTimeCompare(tag(cek[:keySize], iv, ciphertext, aad), expected) != 1 {
+				return nil, ErrDecrypt
+			}
+			if len(ciphertext) == 0 || len(ciphertext)%sealion.BlockSize != 0 {
+				return nil, ErrDecrypt
+			}
+			b, err := newBlock(cek[keySize:])
+			if err != nil {
+				return nil, err
+			}
+			plaintext := make([]byte, len(ciphertext))
+			cipher.NewCBCDecrypter(b, iv).CryptBlocks(plaintext, ciphertext)
+
+			// The tag has been checked, so the padding cannot serve as an oracle
+			pad := int(plaintext[len(plaintext)-1])
+			if pad == 0 || pad > sealion.BlockSize {
+				return nil, ErrDecrypt
+			}
+			for _, p := range plaintext[len(plaintext)-pad:] {
+				if int(p) != pad {
+					return nil, ErrDecrypt
+				}
+			}
+			return plaintext[:len(plaintext)-pad], nil
+		},
+	}
+}
@@ -0,0 +1,155 @@
+package jwe
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func fromHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// TestCBCHMACVector checks the composite encryption against RFC 7518
+// appendix B.1 by running it with AES.
+func TestCBCHMACVector(t *testing.T) {
+	ce := newCBCHMAC(16, sha256.New, aes.NewCipher)
+	key := fromHex("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	iv := fromHex("1af38c2dc2b96ffdd86694092341bc04")
+	plaintext := fromHex("41206369706865722073797374656d206d757374206e6f7420626520726571756972656420746f206265207365637265742c20616e64206974206d7573742062652061626c6520746f2066616c6c20696e746f207468652068616e6473206f662074686520656e656d7920776974686f757420696e636f6e76656e69656e6365")
+	aad := fromHex("546865207365636f6e64207072696e6369706c65206f662041756775737465204b6572636b686f666673")
+	wantCiphertext := fromHex("c80edfa32ddf39d5ef00c0b468834279a2e46a1b8049f792f76bfe54b903a9c9a94ac9b47ad2655c5f10f9aef71427e2fc6f9b3f399a221489f16362c703233609d45ac69864e3321cf82935ac4096c86e133314c54019e8ca7980dfa4b9cf1b384c486f3a54c51078158ee5d79de59fbd34d848b3d69550a67646344427ade54b8851ffb598f7f80074b9473c82e2db")
+	wantTag := fromHex("652c3fa36b0a7c5b3219fab3a30bc1c4")
+
+	ciphertext, tag, err := ce.seal(key, iv, plaintext, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ciphertext, wantCiphertext) || !bytes.Equal(tag, wantTag) {
+		t.Fatalf("got %x %x", ciphertext, tag)
+	}
+	got, err := ce.open(key, iv, ciphertext, tag, aad)
+	if err != nil || !bytes.Equal(got, plaintext) {
+		t.Errorf("open failed: %v", err)
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	for _, alg := range []string{SL128KW, SL192KW, SL256KW, Direct} {
+		for enc, ce := range encryptions {
+			key := bytes.Repeat([]byte{0x42}, algorithms[alg])
+			if alg == Direct {
+				key = bytes.Repeat([]byte{0x42}, ce.keySize)
+			}
+			token, err := Encrypt([]byte("hello jwe"), key, Header{Alg: alg, Enc: enc, Kid: "k1"})
+			if err != nil {
+				t.Fatalf("%s %s: %v", alg, enc, err)
+			}
+			if n := strings.Count(token, "."); n != 4 {
+				t.Errorf("%s %s: token has %d separators", alg, enc, n)
+			}
+			plaintext, header, err := Decrypt(token, key)
+			if err != nil || string(plaintext) != "hello jwe" {
+				t.Errorf("%s %s: round trip failed: %v", alg, enc, err)
+				continue
+			}
+			if header.Alg != alg || header.Enc != enc || header.Kid != "k1" {
+				t.Errorf("%s %s: header %+v", alg, enc, header)
+			}
+		}
+	}
+}
+
+func TestDecryptRejectsTampering(t *testing.T) {
+	key := make([]byte, 16)
+	for enc := range encryptions {
+		token, err := Encrypt([]byte("attack at dawn"), key, Header{Alg: SL128KW, Enc: enc})
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts := strings.Split(token, ".")
+		for i := 1; i < len(parts); i++ {
+			raw, err := base64.RawURLEncoding.DecodeString(parts[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+			raw[0] ^= 1
+			modified := append([]string(nil), parts...)
+			modified[i] = base64.RawURLEncoding.EncodeToString(raw)
+			if _, _, err := Decrypt(strings.Join(modified, "."), key); !errors.Is(err, ErrDecrypt) {
+				t.Errorf("%s: modified part %d gave %v", enc, i, err)
+			}
+		}
+		if _, _, err := Decrypt(token, make([]byte, 24)); err == nil {
+			t.Errorf("%s: wrong key size accepted", enc)
+		}
+	}
+}
+
+func TestDecryptMalformed(t *testing.T) {
+	key := make([]byte, 16)
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"dir","enc":"SL128GCM"}`))
+	for _, token := range []string{
+		"",
+		"a.b.c",
+		"a.b.c.d.e.f",
+		"!!.." + "..",
+		header + ".!!...",
+	} {
+		if _, _, err := Decrypt(token, key); err == nil {
+			t.Errorf("%q accepted", token)
+		}
+	}
+	if _, _, err := Decrypt("a.b.c", key); !errors.Is(err, ErrMalformed) {
+		t.Errorf("three part token gave %v", err)
+	}
+}
+
+func TestEncryptKeySize(t *testing.T) {
+	if _, err := Encrypt(nil, make([]byte, 16), Header{Alg: SL256KW, Enc: SL128GCM}); !errors.Is(err, errKeySize) {
+		t.Errorf("short KEK gave %v", err)
+	}
+	if _, err := Encrypt(nil, make([]byte, 16), Header{Alg: Direct, Enc: SL256CBCHS512}); !errors.Is(err, errKeySize) {
+		t.Errorf("short direct key gave %v", err)
+	}
+	if _, err := Encrypt(nil, make([]byte, 16), Header{Alg: "RSA1_5", Enc: SL128GCM}); err == nil {
+		t.Error("unsupported alg accepted")
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	for _, raw := range []string{
+		`{"ALG":"dir","alg":"dir","enc":"SL128GCM"}`,
+		`{"alg":"dir","Alg":"SL128KW","enc":"SL128GCM"}`,
+		`{"alg":"dir","alg":"dir","enc":"SL128GCM"}`,
+		`{"alg":"dir","enc":"SL128GCM"} {}`,
+		`{"alg":"dir","enc":"SL128GCM"`,
+		`[]`,
+		`{"alg":1,"enc":"SL128GCM"}`,
+		`{"alg":"dir","enc":"SL128GCM","zip":"DEF"}`,
+		`{"alg":"dir","enc":"SL128GCM","crit":["exp"]}`,
+		`{"alg":"RSA1_5","enc":"SL128GCM"}`,
+		`{"alg":"dir","enc":"A128GCM"}`,
+	} {
+		if _, err := parseHeader([]byte(raw)); err == nil {
+			t.Errorf("%s accepted", raw)
+		}
+	}
+
+	h, err := parseHeader([]byte(`{"alg":"dir","enc":"SL128GCM","x":[1,{"a":2}],"kid":"k"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Alg != Direct || h.Enc != SL128GCM || h.Kid != "k" {
+		t.Errorf("header %+v", h)
+	}
+}
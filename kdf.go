@@ -0,0 +1,48 @@
+package sealion
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+var errKDFLength = errors.New("sealion: invalid derived key length")
+
+// DeriveKey derives length bytes of keying material from the SEA-Lion key
+// with the counter mode KDF of NIST SP 800-108, using CMAC as the PRF:
+//
+//	K(i) = CMAC(key, [i]_32 || label || 0x00 || context || [L]_32)
+//
+// where L is the output length in bits. Different labels and contexts yield
+// independent keys.
+func DeriveKey(key, label, context []byte, length int) ([]byte, error) {
+	b, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return deriveKey(b, label, context, length)
+}
+
+func deriveKey(b cipher.Block, label, context []byte, length int) ([]byte, error) {
+	if length <= 0 || uint64(length)*8 > math.MaxUint32 {
+		return nil, errKDFLength
+	}
+
+	k1, k2 := cmacSubkeys(b)
+
+	// The fixed input data after the counter is the same for every block
+	input := make([]byte, 4, 4+len(label)+1+len(context)+4)
+	input = append(input, label...)
+	input = append(input, 0)
+	input = append(input, context...)
+	input = binary.BigEndian.AppendUint32(input, uint32(length*8))
+
+	out := make([]byte, 0, length+BlockSize)
+	for i := uint32(1); len(out) < length; i++ {
+		binary.BigEndian.PutUint32(input, i)
+		k := cmacSum(b, &k1, &k2, input)
+		out = append(out, k[:]...)
+	}
+	return out[:length], nil
+}
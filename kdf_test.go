@@ -0,0 +1,54 @@
+package sealion
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestDeriveKey(t *testing.T) {
+	key := make([]byte, 16)
+	label, context := []byte("encryption"), []byte("tenant-1")
+	out, err := DeriveKey(key, label, context, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 40 {
+		t.Fatalf("derived %d bytes", len(out))
+	}
+
+	// K(i) = CMAC(key, [i]_32 || label || 0x00 || context || [L]_32)
+	mac, _ := NewCMAC(key)
+	var want []byte
+	for i := uint32(1); i <= 3; i++ {
+		mac.Reset()
+		mac.Write(binary.BigEndian.AppendUint32(nil, i))
+		mac.Write(label)
+		mac.Write([]byte{0})
+		mac.Write(context)
+		mac.Write(binary.BigEndian.AppendUint32(nil, 40*8))
+		want = mac.Sum(want)
+	}
+	if !bytes.Equal(out, want[:40]) {
+		t.Errorf("got %x, want %x", out, want[:40])
+	}
+
+	// The output length is an input, so a shorter key is not a prefix
+	short, _ := DeriveKey(key, label, context, 16)
+	if bytes.Equal(short, out[:16]) {
+		t.Error("16-byte key is a prefix of the 40-byte key")
+	}
+	other, _ := DeriveKey(key, label, []byte("tenant-2"), 40)
+	if bytes.Equal(other, out) {
+		t.Error("context has no effect")
+	}
+
+	for _, n := range []int{0, -1} {
+		if _, err := DeriveKey(key, label, context, n); err == nil {
+			t.Errorf("accepted length %d", n)
+		}
+	}
+	if _, err := DeriveKey(make([]byte, 10), label, context, 16); err == nil {
+		t.Error("accepted a 10-byte key")
+	}
+}
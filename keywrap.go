@@ -0,0 +1,195 @@
+package sealion
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"strconv"
+)
+
+// Default initial value of RFC 3394 and alternative initial value prefix of
+// RFC 5649
+var (
+	keyWrapIV       = [8]byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+	keyWrapPadIVMSB = [4]byte{0xa6, 0x59, 0x59, 0xa6}
+)
+
+// ErrKeyWrapIntegrity is returned when an unwrapped key fails the integrity
+// check, meaning the ciphertext was modified or the KEK is wrong.
+var ErrKeyWrapIntegrity = errors.New("sealion: key unwrap integrity check failed")
+
+type KeyWrapLengthError int
+
+func (k KeyWrapLengthError) Error() string {
+	return "sealion: invalid key wrap input length " + strconv.Itoa(int(k))
+}
+
+// Wrap wraps plaintext, a key of at least 16 bytes and a multiple of 8 bytes,
+// under the key-encryption key kek as specified by RFC 3394.
+func Wrap(kek, plaintext []byte) ([]byte, error) {
+	b, err := NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	return wrap(b, plaintext)
+}
+
+// Unwrap reverses Wrap, returning ErrKeyWrapIntegrity if the wrapped key was
+// not produced by Wrap under kek.
+func Unwrap(kek, ciphertext []byte) ([]byte, error) {
+	b, err := NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	return unwrap(b, ciphertext)
+}
+
+// WrapWithPadding wraps plaintext, a key of any non-zero length, under the
+// key-encryption key kek as specified by RFC 5649.
+func WrapWithPadding(kek, plaintext []byte) ([]byte, error) {
+	b, err := NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	return wrapWithPadding(b, plaintext)
+}
+
+// UnwrapWithPadding reverses WrapWithPadding, returning ErrKeyWrapIntegrity
+// if the wrapped key was not produced by WrapWithPadding under kek.
+func UnwrapWithPadding(kek, ciphertext []byte) ([]byte, error) {
+	b, err := NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	return unwrapWithPadding(b, ciphertext)
+}
+
+func wrap(b cipher.Block, plaintext []byte) ([]byte, error) {
+	if len(plaintext) < 16 || len(plaintext)%8 != 0 {
+		return nil, KeyWrapLengthError(len(plaintext))
+	}
+	return wrapRounds(b, keyWrapIV, plaintext), nil
+}
+
+func unwrap(b cipher.Block, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < 24 || len(ciphertext)%8 != 0 {
+		return nil, KeyWrapLengthError(len(ciphertext))
+	}
+
+	a, out := unwrapRounds(b, ciphertext)
+	if subtle.ConstantTimeCompare(a[:], keyWrapIV[:]) != 1 {
+		return nil, ErrKeyWrapIntegrity
+	}
+	return out, nil
+}
+
+func wrapWithPadding(b cipher.Block, plaintext []byte) ([]byte, error) {
+	if len(plaintext) == 0 || uint64(len(plaintext)) > 0xffffffff {
+		return nil, KeyWrapLengthError(len(plaintext))
+	}
+
+	// AIV = A65959A6 || [MLI]_32
+	var aiv [8]byte
+	copy(aiv[:], keyWrapPadIVMSB[:])
+	binary.BigEndian.PutUint32(aiv[4:], uint32(len(plaintext)))
+
+	padded := make([]byte, (len(plaintext)+7)/8*8)
+	copy(padded, plaintext)
+
+	if len(padded) == 8 {
+		// A single padded block is encrypted directly as AIV || P
+		out := make([]byte, BlockSize)
+		copy(out, aiv[:])
+		copy(out[8:], padded)
+		b.Encrypt(out, out)
+		return out, nil
+	}
+	return wrapRounds(b, aiv, padded), nil
+}
+
+func unwrapWithPadding(b cipher.Block, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < 16 || len(ciphertext)%8 != 0 {
+		return nil, KeyWrapLengthError(len(ciphertext))
+	}
+
+	var a [8]byte
+	var padded []byte
+	if len(ciphertext) == BlockSize {
+		var block [BlockSize]byte
+		b.Decrypt(block[:], ciphertext)
+		copy(a[:], block[:8])
+		padded = block[8:]
+	} else {
+		a, padded = unwrapRounds(b, ciphertext)
+	}
+
+	// Check the AIV prefix, that MLI falls within the last 8-byte block and
+	// that the padding is all zeros. MLI is a full 32-bit value, so it is
+	// compared as a uint64; it is not secret once the prefix has matched.
+	if subtle.ConstantTimeCompare(a[:4], keyWrapPadIVMSB[:]) != 1 {
+		return nil, ErrKeyWrapIntegrity
+	}
+	mli := uint64(binary.BigEndian.Uint32(a[4:]))
+	if mli > uint64(len(padded)) || mli+7 < uint64(len(padded)) {
+		return nil, ErrKeyWrapIntegrity
+	}
+	var nonZero byte
+	for _, c := range padded[mli:] {
+		nonZero |= c
+	}
+	if nonZero != 0 {
+		return nil, ErrKeyWrapIntegrity
+	}
+	return padded[:mli], nil
+}
+
+// wrapRounds runs the 6n step wrapping process of RFC 3394 over the 64-bit
+// registers of plaintext with the initial value iv.
+func wrapRounds(b cipher.Block, iv [8]byte, plaintext []byte) []byte {
+	n := len(plaintext) / 8
+	out := make([]byte, 8+len(plaintext))
+	copy(out[8:], plaintext)
+
+	var block [BlockSize]byte
+	copy(block[:8], iv[:])
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			// B = E(K, A | R[i]), A = MSB(64, B) ^ t, R[i] = LSB(64, B)
+			r := out[i*8 : i*8+8]
+			copy(block[8:], r)
+			b.Encrypt(block[:], block[:])
+			t := binary.BigEndian.Uint64(block[:8]) ^ uint64(n*j+i)
+			binary.BigEndian.PutUint64(block[:8], t)
+			copy(r, block[8:])
+		}
+	}
+	copy(out, block[:8])
+	return out
+}
+
+// unwrapRounds inverts wrapRounds, returning the recovered initial value and
+// the plaintext registers.
+func unwrapRounds(b cipher.Block, ciphertext []byte) ([8]byte, []byte) {
+	n := len(ciphertext)/8 - 1
+	out := make([]byte, len(ciphertext)-8)
+	copy(out, ciphertext[8:])
+
+	var block [BlockSize]byte
+	copy(block[:8], ciphertext[:8])
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			// B = D(K, (A ^ t) | R[i]), A = MSB(64, B), R[i] = LSB(64, B)
+			r := out[(i-1)*8 : i*8]
+			t := binary.BigEndian.Uint64(block[:8]) ^ uint64(n*j+i)
+			binary.BigEndian.PutUint64(block[:8], t)
+			copy(block[8:], r)
+			b.Decrypt(block[:], block[:])
+			copy(r, block[8:])
+		}
+	}
+
+	var a [8]byte
+	copy(a[:], block[:8])
+	return a, out
+}
@@ -0,0 +1,115 @@
+package sealion
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// RFC 3394 section 4.1 and RFC 5649 section 6, with AES in place of SEA-Lion
+var keyWrapAESTests = []struct {
+	kek, key, wrapped string
+	padding           bool
+}{
+	{"000102030405060708090A0B0C0D0E0F", "00112233445566778899AABBCCDDEEFF", "1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5", false},
+	{"5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8", "c37b7e6492584340bed12207808941155068f738", "138bdeaa9b8fa7fc61f97742e72248ee5ae6ae5360d1ae6a5f54f373fa543b6a", true},
+	{"5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8", "466f7250617369", "afbeb0f07dfbf5419200f2ccb50bb24f", true},
+}
+
+func TestKeyWrapAES(t *testing.T) {
+	for i, tt := range keyWrapAESTests {
+		b, _ := aes.NewCipher(fromHex(tt.kek))
+		wrapFn, unwrapFn := wrap, unwrap
+		if tt.padding {
+			wrapFn, unwrapFn = wrapWithPadding, unwrapWithPadding
+		}
+
+		key, want := fromHex(tt.key), fromHex(tt.wrapped)
+		wrapped, err := wrapFn(b, key)
+		if err != nil || !bytes.Equal(wrapped, want) {
+			t.Errorf("#%d: got %x, %v, want %x", i, wrapped, err, want)
+			continue
+		}
+		unwrapped, err := unwrapFn(b, wrapped)
+		if err != nil || !bytes.Equal(unwrapped, key) {
+			t.Errorf("#%d: unwrap failed: %x, %v", i, unwrapped, err)
+		}
+	}
+}
+
+// TestUnwrapWithPaddingMLI wraps blocks under a valid AIV prefix but with a
+// message length indicator outside the last semiblock.
+func TestUnwrapWithPaddingMLI(t *testing.T) {
+	b, _ := NewCipher(make([]byte, 16))
+	padded := make([]byte, 16)
+	for _, tt := range []struct {
+		mli uint32
+		ok  bool
+	}{
+		{8, false},
+		{9, true},
+		{16, true},
+		{17, false},
+		{0x7fffffff, false},
+		{0x80000000, false},
+		{0xffffffff, false},
+	} {
+		var aiv [8]byte
+		copy(aiv[:], keyWrapPadIVMSB[:])
+		binary.BigEndian.PutUint32(aiv[4:], tt.mli)
+		out, err := unwrapWithPadding(b, wrapRounds(b, aiv, padded))
+		if tt.ok && (err != nil || len(out) != int(tt.mli)) {
+			t.Errorf("MLI %#x: got %d bytes, %v", tt.mli, len(out), err)
+		}
+		if !tt.ok && !errors.Is(err, ErrKeyWrapIntegrity) {
+			t.Errorf("MLI %#x gave %v", tt.mli, err)
+		}
+	}
+}
+
+func TestKeyWrap(t *testing.T) {
+	kek := make([]byte, 32)
+	for _, tt := range []struct {
+		wrap, unwrap func(kek, in []byte) ([]byte, error)
+		lengths      []int
+	}{
+		{Wrap, Unwrap, []int{16, 24, 32, 64}},
+		{WrapWithPadding, UnwrapWithPadding, []int{1, 7, 8, 9, 16, 20, 33}},
+	} {
+		for _, n := range tt.lengths {
+			key := bytes.Repeat([]byte{0x5a}, n)
+			wrapped, err := tt.wrap(kek, key)
+			if err != nil {
+				t.Fatalf("length %d: %v", n, err)
+			}
+			unwrapped, err := tt.unwrap(kek, wrapped)
+			if err != nil || !bytes.Equal(unwrapped, key) {
+				t.Errorf("length %d: round trip failed: %v", n, err)
+			}
+
+			wrapped[len(wrapped)-1] ^= 1
+			if _, err := tt.unwrap(kek, wrapped); !errors.Is(err, ErrKeyWrapIntegrity) {
+				t.Errorf("length %d: modified wrapped key gave %v", n, err)
+			}
+			wrapped[len(wrapped)-1] ^= 1
+			if _, err := tt.unwrap(make([]byte, 16), wrapped); !errors.Is(err, ErrKeyWrapIntegrity) {
+				t.Errorf("length %d: wrong KEK gave %v", n, err)
+			}
+		}
+	}
+
+	var lengthErr KeyWrapLengthError
+	for _, n := range []int{0, 8, 17} {
+		if _, err := Wrap(kek, make([]byte, n)); !errors.As(err, &lengthErr) {
+			t.Errorf("Wrap of %d bytes gave %v", n, err)
+		}
+	}
+	if _, err := WrapWithPadding(kek, nil); !errors.As(err, &lengthErr) {
+		t.Errorf("WrapWithPadding of no bytes gave %v", err)
+	}
+	if _, err := Unwrap(kek, make([]byte, 12)); !errors.As(err, &lengthErr) {
+		t.Errorf("Unwrap of 12 bytes gave %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package sealion
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+	"math/bits"
+)
+
+const (
+	ocbNonceSize    = 12
+	ocbMaxNonceSize = 15
+	ocbTagSize      = 16
+	ocbMinTagSize   = 8
+	// Number of precomputed L_i values, enough for messages of 2^64 blocks
+	ocbLTableSize = 64
+)
+
+type ocb struct {
+	b         cipher.Block
+	nonceSize int
+	tagSize   int
+	lStar     [BlockSize]byte
+	lDollar   [BlockSize]byte
+	l         [ocbLTableSize][BlockSize]byte
+}
+
+// NewOCB returns the OCB3 (RFC 7253) mode of the given 128-bit block cipher
+// with the standard 12-byte nonce and 16-byte tag.
+func NewOCB(b cipher.Block) (cipher.AEAD, error) {
+	return NewOCBWithNonceAndTagSize(b, ocbNonceSize, ocbTagSize)
+}
+
+// NewOCBWithNonceAndTagSize returns the OCB3 mode of the given 128-bit block
+// cipher accepting nonces of nonceSize bytes (1 to 15) and producing tags of
+// tagSize bytes (8 to 16).
+func NewOCBWithNonceAndTagSize(b cipher.Block, nonceSize, tagSize int) (cipher.AEAD, error) {
+	if b.BlockSize() != BlockSize {
+		return nil, errors.New("sealion: OCB requires a 128-bit block cipher")
+	}
+	if nonceSize < 1 || nonceSize > ocbMaxNonceSize {
+		return nil, errors.New("sealion: invalid OCB nonce size")
+	}
+	if tagSize < ocbMinTagSize || tagSize > ocbTagSize {
+		return nil, errors.New("sealion: invalid OCB tag size")
+	}
+
+	o := &ocb{b: b, nonceSize: nonceSize, tagSize: tagSize}
+
+	// L_* = ENCIPHER(K, zeros(128)), L_$ = double(L_*), L_0 = double(L_$)
+	b.Encrypt(o.lStar[:], o.lStar[:])
+	o.lDollar = o.lStar
+	dbl(&o.lDollar)
+	o.l[0] = o.lDollar
+	dbl(&o.l[0])
+	for i := 1; i < ocbLTableSize; i++ {
+		o.l[i] = o.l[i-1]
+		dbl(&o.l[i])
+	}
+
+	return o, nil
+}
+
+func (o *ocb) NonceSize() int {
+	return o.nonceSize
+}
+
+func (o *ocb) Overhead() int {
+	return o.tagSize
+}
+
+func (o *ocb) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != o.nonceSize {
+		panic("sealion: incorrect nonce length given to OCB")
+	}
+
+	ret, out := sliceForAppend(dst, len(plaintext)+o.tagSize)
+	if inexactOverlap(out, plaintext) {
+		panic("sealion: invalid buffer overlap")
+	}
+
+	var tag [BlockSize]byte
+	o.crypt(out[:len(plaintext)], plaintext, nonce, additionalData, &tag, false)
+	copy(out[len(plaintext):], tag[:o.tagSize])
+	return ret
+}
+
+func (o *ocb) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != o.nonceSize {
+		panic("sealion: incorrect nonce length given to OCB")
+	}
+	if len(ciphertext) < o.tagSize {
+		return nil, errOpen
+	}
+
+	tag := ciphertext[len(ciphertext)-o.tagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-o.tagSize]
+
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	if inexactOverlap(out, ciphertext) {
+		panic("sealion: invalid buffer overlap")
+	}
+
+	var expected [BlockSize]byte
+	o.crypt(out, ciphertext, nonce, additionalData, &expected, true)
+	if subtle.ConstantTimeCompare(expected[:o.tagSize], tag) != 1 {
+		for i := range out {
+			out[i] = 0
+		}
+		return nil, errOpen
+	}
+	return ret, nil
+}
+
+// crypt runs the OCB encryption or decryption pass over src and stores the
+// full-length tag in tag.
+func (o *ocb) crypt(dst, src, nonce, additionalData []byte, tag *[BlockSize]byte, decrypt bool) {
+	offset := o.initialOffset(nonce)
+	var checksum, tmp [BlockSize]byte
+
+	i := 1
+	for ; len(src) >= BlockSize; i++ {
+		// Offset_i = Offset_{i-1} xor L_{ntz(i)}
+		subtle.XORBytes(offset[:], offset[:], o.l[bits.TrailingZeros(uint(i))][:])
+		subtle.XORBytes(tmp[:], src[:BlockSize], offset[:])
+		if decrypt {
+			o.b.Decrypt(tmp[:], tmp[:])
+			subtle.XORBytes(dst[:BlockSize], tmp[:], offset[:])
+			subtle.XORBytes(checksum[:], checksum[:], dst[:BlockSize])
+		} else {
+			subtle.XORBytes(checksum[:], checksum[:], src[:BlockSize])
+			o.b.Encrypt(tmp[:], tmp[:])
+			subtle.XORBytes(dst[:BlockSize], tmp[:], offset[:])
+		}
+		src, dst = src[BlockSize:], dst[BlockSize:]
+	}
+
+	if len(src) > 0 {
+		// Offset_* = Offset_m xor L_*, Pad = ENCIPHER(K, Offset_*)
+		subtle.XORBytes(offset[:], offset[:], o.lStar[:])
+		o.b.Encrypt(tmp[:], offset[:])
+		if decrypt {
+			subtle.XORBytes(dst, src, tmp[:])
+			subtle.XORBytes(checksum[:], checksum[:], dst)
+		} else {
+			subtle.XORBytes(checksum[:], checksum[:], src)
+			subtle.XORBytes(dst, src, tmp[:])
+		}
+		checksum[len(src)] ^= 0x80
+	}
+
+	// Tag = ENCIPHER(K, Checksum xor Offset xor L_$) xor HASH(K, A)
+	subtle.XORBytes(tag[:], checksum[:], offset[:])
+	subtle.XORBytes(tag[:], tag[:], o.lDollar[:])
+	o.b.Encrypt(tag[:], tag[:])
+	hash := o.hash(additionalData)
+	subtle.XORBytes(tag[:], tag[:], hash[:])
+}
+
+// initialOffset computes Offset_0 from the nonce and tag length.
+func (o *ocb) initialOffset(nonce []byte) [BlockSize]byte {
+	// Nonce = num2str(TAGLEN mod 128, 7) || zeros || 1 || N
+	var n [BlockSize]byte
+	n[0] = byte(o.tagSize*8%128) << 1
+	n[BlockSize-1-len(nonce)] |= 1
+	copy(n[BlockSize-len(nonce):], nonce)
+
+	bottom := int(n[BlockSize-1] & 0x3f)
+	n[BlockSize-1] &= 0xc0
+
+	// Stretch = Ktop || (Ktop[1..64] xor Ktop[9..72])
+	var stretch [BlockSize + 8]byte
+	o.b.Encrypt(stretch[:BlockSize], n[:])
+	subtle.XORBytes(stretch[BlockSize:], stretch[:8], stretch[1:9])
+
+	// Offset_0 = Stretch[1+bottom..128+bottom]
+	var offset [BlockSize]byte
+	byteShift, bitShift := bottom/8, uint(bottom%8)
+	for i := range offset {
+		offset[i] = stretch[i+byteShift] << bitShift
+		if bitShift > 0 {
+			offset[i] |= stretch[i+byteShift+1] >> (8 - bitShift)
+		}
+	}
+	return offset
+}
+
+// hash computes HASH(K, A) over the associated data.
+func (o *ocb) hash(a []byte) [BlockSize]byte {
+	var sum, offset, tmp [BlockSize]byte
+
+	for i := 1; len(a) >= BlockSize; i++ {
+		subtle.XORBytes(offset[:], offset[:], o.l[bits.TrailingZeros(uint(i))][:])
+		subtle.XORBytes(tmp[:], a[:BlockSize], offset[:])
+		o.b.Encrypt(tmp[:], tmp[:])
+		subtle.XORBytes(sum[:], sum[:], tmp[:])
+		a = a[BlockSize:]
+	}
+
+	if len(a) > 0 {
+		subtle.XORBytes(offset[:], offset[:], o.lStar[:])
+		tmp = offset
+		subtle.XORBytes(tmp[:], tmp[:], a)
+		tmp[len(a)] ^= 0x80
+		o.b.Encrypt(tmp[:], tmp[:])
+		subtle.XORBytes(sum[:], sum[:], tmp[:])
+	}
+	return sum
+}
@@ -0,0 +1,126 @@
+package sealion
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"testing"
+)
+
+// RFC 7253, Appendix A, with AES in place of SEA-Lion
+var ocbAESTests = []struct {
+	nonce, ad, plaintext, ciphertext string
+}{
+	{"BBAA99887766554433221100", "", "", "785407BFFFC8AD9EDCC5520AC9111EE6"},
+	{"BBAA99887766554433221101", "0001020304050607", "0001020304050607", "6820B3657B6F615A5725BDA0D3B4EB3A257C9AF1F8F03009"},
+	{"BBAA99887766554433221104", "000102030405060708090A0B0C0D0E0F", "000102030405060708090A0B0C0D0E0F", "571D535B60B277188BE5147170A9A22C3AD7A4FF3835B8C5701C1CCEC8FC3358"},
+	{"BBAA99887766554433221107", "000102030405060708090A0B0C0D0E0F1011121314151617", "000102030405060708090A0B0C0D0E0F1011121314151617", "1CA2207308C87C010756104D8840CE1952F09673A448A122C92C62241051F57356D7F3C90BB0E07F"},
+}
+
+func TestOCBAES(t *testing.T) {
+	b, _ := aes.NewCipher(fromHex("000102030405060708090A0B0C0D0E0F"))
+	ocb, err := NewOCB(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tt := range ocbAESTests {
+		checkAEAD(t, i, ocb, tt.nonce, tt.ad, tt.plaintext, tt.ciphertext)
+	}
+
+	// 96-bit tag
+	b, _ = aes.NewCipher(fromHex("0F0E0D0C0B0A09080706050403020100"))
+	ocb, err = NewOCBWithNonceAndTagSize(b, 12, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := "000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F2021222324252627"
+	checkAEAD(t, 0, ocb, "BBAA9988776655443322110D", msg, msg,
+		"1792A4E31E0755FB03E31B22116E6C2DDF9EFD6E33D536F1A0124B0A55BAE884ED93481529C76B6AD0C515F4D1CDD4FDAC4F02AA")
+}
+
+func TestOCB(t *testing.T) {
+	b, _ := NewCipher(make([]byte, 16))
+	ocb, err := NewOCB(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testAEAD(t, ocb)
+
+	if _, err := NewOCBWithNonceAndTagSize(b, 16, 16); err == nil {
+		t.Error("accepted a 16-byte nonce")
+	}
+	if _, err := NewOCBWithNonceAndTagSize(b, 12, 7); err == nil {
+		t.Error("accepted a 7-byte tag")
+	}
+}
+
+// checkAEAD seals a known-answer vector and opens the result again.
+func checkAEAD(t *testing.T, i int, aead cipher.AEAD, nonce, ad, plaintext, ciphertext string) {
+	t.Helper()
+	n, a, p, want := fromHex(nonce), fromHex(ad), fromHex(plaintext), fromHex(ciphertext)
+	ct := aead.Seal(nil, n, p, a)
+	if !bytes.Equal(ct, want) {
+		t.Errorf("#%d: got %X, want %X", i, ct, want)
+		return
+	}
+	pt, err := aead.Open(nil, n, ct, a)
+	if err != nil || !bytes.Equal(pt, p) {
+		t.Errorf("#%d: Open failed: %v", i, err)
+	}
+}
+
+// testAEAD checks that aead round-trips messages of various lengths, also in
+// place, and rejects modified ciphertexts, nonces and additional data.
+func testAEAD(t *testing.T, aead cipher.AEAD) {
+	t.Helper()
+	nonce := make([]byte, aead.NonceSize())
+	for i := range nonce {
+		nonce[i] = byte(i)
+	}
+	ad := []byte("additional data")
+
+	for _, n := range []int{0, 1, 15, 16, 17, 31, 32, 33, 100, 1000} {
+		plaintext := make([]byte, n)
+		for i := range plaintext {
+			plaintext[i] = byte(i * 3)
+		}
+
+		ct := aead.Seal([]byte("prefix"), nonce, plaintext, ad)
+		if !bytes.HasPrefix(ct, []byte("prefix")) || len(ct) != len("prefix")+n+aead.Overhead() {
+			t.Fatalf("length %d: bad Seal output %x", n, ct)
+		}
+		ct = ct[len("prefix"):]
+
+		pt, err := aead.Open(nil, nonce, ct, ad)
+		if err != nil || !bytes.Equal(pt, plaintext) {
+			t.Fatalf("length %d: Open failed: %v", n, err)
+		}
+
+		inPlace := append(make([]byte, 0, n+aead.Overhead()), plaintext...)
+		inPlace = aead.Seal(inPlace[:0], nonce, inPlace, ad)
+		if !bytes.Equal(inPlace, ct) {
+			t.Fatalf("length %d: in-place Seal differs", n)
+		}
+		if pt, err := aead.Open(inPlace[:0], nonce, inPlace, ad); err != nil || !bytes.Equal(pt, plaintext) {
+			t.Fatalf("length %d: in-place Open failed: %v", n, err)
+		}
+
+		for i := range ct {
+			ct[i] ^= 0x80
+			if _, err := aead.Open(nil, nonce, ct, ad); err == nil {
+				t.Fatalf("length %d: opened ciphertext modified at byte %d", n, i)
+			}
+			ct[i] ^= 0x80
+		}
+		if _, err := aead.Open(nil, nonce, ct, []byte("other data")); err == nil {
+			t.Fatalf("length %d: opened with wrong additional data", n)
+		}
+		if len(nonce) > 0 {
+			nonce[0] ^= 1
+			if _, err := aead.Open(nil, nonce, ct, ad); err == nil {
+				t.Fatalf("length %d: opened with wrong nonce", n)
+			}
+			nonce[0] ^= 1
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package sealion
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+const (
+	// DefaultPasswordIterations is the PBKDF2 iteration count used by
+	// EncryptWithPassword.
+	DefaultPasswordIterations = 100000
+	// Upper bound on the iteration count accepted from a header, which limits
+	// the work a forged header can cost DecryptWithPassword to ten times that
+	// of a genuine one
+	maxPasswordIterations = 10 * DefaultPasswordIterations
+
+	passwordVersion   = 0x01
+	passwordSaltSize  = 16
+	passwordKeySize   = 32
+	passwordHeaderLen = 1 + 4 + passwordSaltSize
+)
+
+var (
+	errPBKDF2Params     = errors.New("sealion: invalid PBKDF2 parameters")
+	errPasswordHeader   = errors.New("sealion: invalid password encryption header")
+	errPasswordDecrypt  = errors.New("sealion: wrong password or corrupted ciphertext")
+	errPasswordTooShort = errors.New("sealion: password encrypted data too short")
+)
+
+// PBKDF2 derives a key of keyLen bytes from password and salt with PBKDF2
+// (RFC 8018), using SEA-Lion CMAC as the PRF. Passwords of any length are
+// accepted: as in RFC 4615, a password that is not exactly 16 bytes is first
+// compressed into a CMAC key by taking its CMAC under the all-zero key.
+func PBKDF2(password, salt []byte, iterations, keyLen int) ([]byte, error) {
+	return pbkdf2(NewCipher, password, salt, iterations, keyLen)
+}
+
+func pbkdf2(newBlock func([]byte) (cipher.Block, error), password, salt []byte, iterations, keyLen int) ([]byte, error) {
+	if iterations < 1 || keyLen < 1 || uint64(keyLen) > uint64(math.MaxUint32)*BlockSize {
+		return nil, errPBKDF2Params
+	}
+
+	key := password
+	if len(key) != BlockSize {
+		zero, err := newBlock(make([]byte, BlockSize))
+		if err != nil {
+			return nil, err
+		}
+		k1, k2 := cmacSubkeys(zero)
+		sum := cmacSum(zero, &k1, &k2, password)
+		key = sum[:]
+	}
+	b, err := newBlock(key)
+	if err != nil {
+		return nil, err
+	}
+	k1, k2 := cmacSubkeys(b)
+
+	// T_i = U_1 xor ... xor U_c, U_1 = PRF(P, S || INT(i)), U_j = PRF(P, U_{j-1})
+	msg := make([]byte, len(salt)+4)
+	copy(msg, salt)
+	out := make([]byte, 0, keyLen+BlockSize)
+	for i := uint32(1); len(out) < keyLen; i++ {
+		binary.BigEndian.PutUint32(msg[len(salt):], i)
+		u := cmacSum(b, &k1, &k2, msg)
+		t := u
+		for j := 1; j < iterations; j++ {
+			u = cmacSum(b, &k1, &k2, u[:])
+			subtle.XORBytes(t[:], t[:], u[:])
+		}
+		out = append(out, t[:]...)
+	}
+	return out[:keyLen], nil
+}
+
+// EncryptWithPassword encrypts and authenticates plaintext under a key derived
+// from password with PBKDF2 and a random salt. The output starts with a small
+// header holding a version byte, the iteration count and the salt, followed
+// by the OCB nonce and ciphertext; the header is authenticated too.
+func EncryptWithPassword(password, plaintext []byte) ([]byte, error) {
+	header := make([]byte, passwordHeaderLen)
+	header[0] = passwordVersion
+	binary.BigEndian.PutUint32(header[1:5], DefaultPasswordIterations)
+	if _, err := rand.Read(header[5:]); err != nil {
+		return nil, err
+	}
+
+	aead, err := passwordAEAD(password, header)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, passwordHeaderLen+aead.NonceSize(), passwordHeaderLen+aead.NonceSize()+len(plaintext)+aead.Overhead())
+	copy(out, header)
+	nonce := out[passwordHeaderLen:]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(out, nonce, plaintext, header), nil
+}
+
+// DecryptWithPassword reverses EncryptWithPassword, reading the salt and
+// iteration count from the header.
+func DecryptWithPassword(password, data []byte) ([]byte, error) {
+	if len(data) < passwordHeaderLen {
+		return nil, errPasswordTooShort
+	}
+	header := data[:passwordHeaderLen]
+	if header[0] != passwordVersion {
+		return nil, errPasswordHeader
+	}
+
+	aead, err := passwordAEAD(password, header)
+	if err != nil {
+		return nil, err
+	}
+
+	data = data[passwordHeaderLen:]
+	if len(data) < aead.NonceSize()+aead.Overhead() {
+		return nil, errPasswordTooShort
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], header)
+	if err != nil {
+		return nil, errPasswordDecrypt
+	}
+	return plaintext, nil
+}
+
+// passwordAEAD derives the key described by header and returns OCB under it.
+func passwordAEAD(password, header []byte) (cipher.AEAD, error) {
+	iterations := binary.BigEndian.Uint32(header[1:5])
+	if iterations < 1 || iterations > maxPasswordIterations {
+		return nil, errPasswordHeader
+	}
+
+	key, err := PBKDF2(password, header[5:passwordHeaderLen], int(iterations), passwordKeySize)
+	if err != nil {
+		return nil, err
+	}
+	b, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return NewOCB(b)
+}
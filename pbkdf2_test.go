@@ -0,0 +1,124 @@
+package sealion
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/subtle"
+	"encoding/binary"
+	"testing"
+)
+
+// aesCMACPRF is AES-CMAC-PRF-128 from RFC 4615.
+func aesCMACPRF(key, msg []byte) []byte {
+	if len(key) != BlockSize {
+		zero, _ := aes.NewCipher(make([]byte, BlockSize))
+		k1, k2 := cmacSubkeys(zero)
+		sum := cmacSum(zero, &k1, &k2, key)
+		key = sum[:]
+	}
+	b, _ := aes.NewCipher(key)
+	k1, k2 := cmacSubkeys(b)
+	sum := cmacSum(b, &k1, &k2, msg)
+	return sum[:]
+}
+
+// RFC 4615 section 4
+var cmacPRFTests = []struct {
+	key, output string
+}{
+	{"000102030405060708090a0b0c0d0e0fedcb", "84a348a4a45d235babfffc0d2b4da09a"},
+	{"000102030405060708090a0b0c0d0e0f", "980ae87b5f4c9c5214f5b6a8455e4c2d"},
+	{"00010203040506070809", "290d9e112edb09ee141fcf64c0b72f3d"},
+}
+
+func TestPBKDF2AES(t *testing.T) {
+	msg := fromHex("000102030405060708090a0b0c0d0e0f10111213")
+	for i, tt := range cmacPRFTests {
+		if got, want := aesCMACPRF(fromHex(tt.key), msg), fromHex(tt.output); !bytes.Equal(got, want) {
+			t.Fatalf("PRF #%d: got %x, want %x", i, got, want)
+		}
+	}
+
+	// T_i = U_1 xor ... xor U_c with U_1 = PRF(P, S || INT(i)), U_j = PRF(P, U_{j-1})
+	password, salt := []byte("password"), []byte("salt")
+	for _, iterations := range []int{1, 2, 5} {
+		var want []byte
+		for i := uint32(1); i <= 3; i++ {
+			u := aesCMACPRF(password, binary.BigEndian.AppendUint32(append([]byte(nil), salt...), i))
+			block := append([]byte(nil), u...)
+			for j := 1; j < iterations; j++ {
+				u = aesCMACPRF(password, u)
+				subtle.XORBytes(block, block, u)
+			}
+			want = append(want, block...)
+		}
+
+		got, err := pbkdf2(aes.NewCipher, password, salt, iterations, 40)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want[:40]) {
+			t.Errorf("%d iterations: got %x, want %x", iterations, got, want[:40])
+		}
+	}
+}
+
+func TestPBKDF2(t *testing.T) {
+	a, err := PBKDF2([]byte("password"), []byte("salt"), 10, 32)
+	if err != nil || len(a) != 32 {
+		t.Fatal(err)
+	}
+	if b, _ := PBKDF2([]byte("passwore"), []byte("salt"), 10, 32); bytes.Equal(a, b) {
+		t.Error("password has no effect")
+	}
+	if b, _ := PBKDF2([]byte("password"), []byte("salu"), 10, 32); bytes.Equal(a, b) {
+		t.Error("salt has no effect")
+	}
+	if b, _ := PBKDF2([]byte("password"), []byte("salt"), 11, 32); bytes.Equal(a, b) {
+		t.Error("iteration count has no effect")
+	}
+
+	for _, params := range [][2]int{{0, 16}, {1, 0}} {
+		if _, err := PBKDF2([]byte("password"), nil, params[0], params[1]); err == nil {
+			t.Errorf("accepted %d iterations and length %d", params[0], params[1])
+		}
+	}
+}
+
+func TestEncryptWithPassword(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs PBKDF2 with the default iteration count")
+	}
+	password, plaintext := []byte("correct horse"), []byte("battery staple")
+	data, err := EncryptWithPassword(password, plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := EncryptWithPassword(password, plaintext); bytes.Equal(again, data) {
+		t.Error("two encryptions are identical")
+	}
+
+	got, err := DecryptWithPassword(password, data)
+	if err != nil || !bytes.Equal(got, plaintext) {
+		t.Fatalf("round trip failed: %v", err)
+	}
+	if _, err := DecryptWithPassword([]byte("wrong horse"), data); err == nil {
+		t.Error("decrypted with the wrong password")
+	}
+
+	// The salt is authenticated as part of the header
+	data[passwordHeaderLen-1] ^= 1
+	if _, err := DecryptWithPassword(password, data); err == nil {
+		t.Error("decrypted with a modified salt")
+	}
+	data[passwordHeaderLen-1] ^= 1
+
+	forged := append([]byte(nil), data...)
+	binary.BigEndian.PutUint32(forged[1:5], maxPasswordIterations+1)
+	if _, err := DecryptWithPassword(password, forged); err == nil {
+		t.Error("accepted an iteration count above the limit")
+	}
+	if _, err := DecryptWithPassword(password, data[:passwordHeaderLen]); err == nil {
+		t.Error("accepted truncated data")
+	}
+}
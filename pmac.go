@@ -0,0 +1,188 @@
+package sealion
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+	"hash"
+	"math/bits"
+	"sync"
+)
+
+const (
+	// Number of precomputed L(i) values, enough for messages of 2^64 blocks
+	pmacLTableSize = 64
+	// Minimum number of bytes handed to each goroutine by a parallel PMAC
+	pmacParallelChunk = 64 * 1024
+)
+
+// pmac implements PMAC1 as a streaming hash.Hash. Every full block except the
+// last is masked with its own offset and enciphered independently, which lets
+// large writes be spread across goroutines.
+type pmac struct {
+	b       cipher.Block
+	l       [pmacLTableSize][BlockSize]byte // L(i) = L·x^i
+	lInv    [BlockSize]byte                 // L·x^-1
+	workers int
+
+	offset  [BlockSize]byte
+	sigma   [BlockSize]byte
+	counter uint64          // number of blocks absorbed into sigma
+	buf     [BlockSize]byte // pending block, only processed once more data follows
+	n       int
+}
+
+// NewPMAC returns a hash.Hash computing the PMAC of the data written to it
+// under a SEA-Lion key. Sum appends a 16-byte tag.
+func NewPMAC(key []byte) (hash.Hash, error) {
+	return NewParallelPMAC(key, 1)
+}
+
+// NewParallelPMAC is like NewPMAC but spreads large writes across up to
+// workers goroutines. The resulting tags are identical to those of NewPMAC.
+func NewParallelPMAC(key []byte, workers int) (hash.Hash, error) {
+	if workers < 1 {
+		return nil, errors.New("sealion: invalid PMAC worker count")
+	}
+	b, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return newPMAC(b, workers), nil
+}
+
+func newPMAC(b cipher.Block, workers int) *pmac {
+	p := &pmac{b: b, workers: workers}
+
+	// L = E(0^n), L(i+1) = double(L(i))
+	b.Encrypt(p.l[0][:], p.l[0][:])
+	for i := 1; i < pmacLTableSize; i++ {
+		p.l[i] = p.l[i-1]
+		dbl(&p.l[i])
+	}
+
+	// L·x^-1 halves L, folding the reduction polynomial back in on a set
+	// low bit
+	p.lInv = p.l[0]
+	carry := p.lInv[BlockSize-1] & 1
+	for i := BlockSize - 1; i > 0; i-- {
+		p.lInv[i] = p.lInv[i]>>1 | p.lInv[i-1]<<7
+	}
+	p.lInv[0] >>= 1
+	p.lInv[0] ^= 0x80 & -carry
+	p.lInv[BlockSize-1] ^= 0x43 & -carry
+
+	return p
+}
+
+func (p *pmac) Size() int {
+	return BlockSize
+}
+
+func (p *pmac) BlockSize() int {
+	return BlockSize
+}
+
+func (p *pmac) Reset() {
+	p.offset = [BlockSize]byte{}
+	p.sigma = [BlockSize]byte{}
+	p.counter = 0
+	p.buf = [BlockSize]byte{}
+	p.n = 0
+}
+
+func (p *pmac) Write(data []byte) (int, error) {
+	written := len(data)
+	for len(data) > 0 {
+		if p.n == BlockSize {
+			p.absorb(p.buf[:])
+			p.n = 0
+		}
+		if p.n == 0 && len(data) > BlockSize {
+			// Absorb every full block that cannot be the final one straight
+			// from data
+			full := (len(data) - 1) / BlockSize * BlockSize
+			p.absorb(data[:full])
+			data = data[full:]
+		}
+		m := copy(p.buf[p.n:], data)
+		p.n += m
+		data = data[m:]
+	}
+	return written, nil
+}
+
+func (p *pmac) Sum(in []byte) []byte {
+	sigma := p.sigma
+	subtle.XORBytes(sigma[:], sigma[:], p.buf[:p.n])
+	if p.n == BlockSize {
+		subtle.XORBytes(sigma[:], sigma[:], p.lInv[:])
+	} else {
+		sigma[p.n] ^= 0x80
+	}
+	p.b.Encrypt(sigma[:], sigma[:])
+	return append(in, sigma[:]...)
+}
+
+// absorb adds the full blocks of src to sigma, in parallel when src is large
+// enough to be worth it.
+func (p *pmac) absorb(src []byte) {
+	blocks := len(src) / BlockSize
+	workers := p.workers
+	if limit := len(src) / pmacParallelChunk; workers > limit {
+		workers = limit
+	}
+	if workers < 2 {
+		p.offset = p.absorbRange(src, p.offset, p.counter, &p.sigma)
+		p.counter += uint64(blocks)
+		return
+	}
+
+	// Spread the blocks evenly so every worker gets a non-empty range
+	partial := make([][BlockSize]byte, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		start, end := w*blocks/workers, (w+1)*blocks/workers
+		wg.Add(1)
+		go func(w, start, end int) {
+			defer wg.Done()
+			first := p.counter + uint64(start)
+			p.absorbRange(src[start*BlockSize:end*BlockSize], p.offsetAt(first), first, &partial[w])
+		}(w, start, end)
+	}
+	wg.Wait()
+
+	for w := range partial {
+		subtle.XORBytes(p.sigma[:], p.sigma[:], partial[w][:])
+	}
+	p.counter += uint64(blocks)
+	p.offset = p.offsetAt(p.counter)
+}
+
+// absorbRange adds the blocks of src, which follow block number counter and
+// its offset, to sigma and returns the offset of the last block.
+func (p *pmac) absorbRange(src []byte, offset [BlockSize]byte, counter uint64, sigma *[BlockSize]byte) [BlockSize]byte {
+	var tmp [BlockSize]byte
+	for len(src) > 0 {
+		// Offset_i = Offset_{i-1} xor L(ntz(i))
+		counter++
+		subtle.XORBytes(offset[:], offset[:], p.l[bits.TrailingZeros64(counter)][:])
+		subtle.XORBytes(tmp[:], src[:BlockSize], offset[:])
+		p.b.Encrypt(tmp[:], tmp[:])
+		subtle.XORBytes(sigma[:], sigma[:], tmp[:])
+		src = src[BlockSize:]
+	}
+	return offset
+}
+
+// offsetAt returns the offset of block i directly: it is the sum of L(k) over
+// the set bits k of the Gray code of i.
+func (p *pmac) offsetAt(i uint64) [BlockSize]byte {
+	var offset [BlockSize]byte
+	for g, k := i^i>>1, 0; g != 0; g, k = g>>1, k+1 {
+		if g&1 == 1 {
+			subtle.XORBytes(offset[:], offset[:], p.l[k][:])
+		}
+	}
+	return offset
+}
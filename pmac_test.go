@@ -0,0 +1,87 @@
+package sealion
+
+import (
+	"bytes"
+	"crypto/aes"
+	"testing"
+)
+
+// PMAC1 reference vectors for AES-128 with key 000102...0f; messages are
+// 00 01 02 ... of the given length, or zeros where zero is set
+var pmacAESTests = []struct {
+	length int
+	zero   bool
+	tag    string
+}{
+	{0, false, "4399572cd6ea5341b8d35876a7098af7"},
+	{3, false, "256ba5193c1b991b4df0c51f388a9e27"},
+	{16, false, "ebbd822fa458daf6dfdad7c27da76338"},
+	{20, false, "0412ca150bbf79058d8c75a58c993f55"},
+	{32, false, "e97ac04e9e5e3399ce5355cd7407bc75"},
+	{34, false, "5cba7d5eb24f7c86ccc54604e53d5512"},
+	{1000, true, "c2c9fa1d9985f6f0d2aff915a0e8d910"},
+}
+
+func TestPMACAES(t *testing.T) {
+	b, _ := aes.NewCipher(fromHex("000102030405060708090a0b0c0d0e0f"))
+	for i, tt := range pmacAESTests {
+		msg := make([]byte, tt.length)
+		if !tt.zero {
+			for j := range msg {
+				msg[j] = byte(j)
+			}
+		}
+		p := newPMAC(b, 1)
+		p.Write(msg)
+		if got, want := p.Sum(nil), fromHex(tt.tag); !bytes.Equal(got, want) {
+			t.Errorf("#%d: got %x, want %x", i, got, want)
+		}
+	}
+}
+
+func TestParallelPMAC(t *testing.T) {
+	key := make([]byte, 16)
+	msg := make([]byte, 1<<20+5)
+	for i := range msg {
+		msg[i] = byte(i * 7)
+	}
+
+	serial, _ := NewPMAC(key)
+	serial.Write(msg)
+	want := serial.Sum(nil)
+
+	for _, workers := range []int{2, 3, 8, 64} {
+		p, err := NewParallelPMAC(key, workers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Uneven writes exercise buffering across parallel batches
+		for m := msg; len(m) > 0; {
+			n := min(300001, len(m))
+			p.Write(m[:n])
+			m = m[n:]
+		}
+		if got := p.Sum(nil); !bytes.Equal(got, want) {
+			t.Errorf("%d workers: got %x, want %x", workers, got, want)
+		}
+	}
+}
+
+// With more workers than the input has blocks per worker, the last worker
+// ranges used to start past the end of the input.
+func TestParallelPMACManyWorkers(t *testing.T) {
+	if testing.Short() {
+		t.Skip("hashes over 300 MiB")
+	}
+	// AES keeps this fast; the split does not depend on the block cipher
+	b, _ := aes.NewCipher(make([]byte, 16))
+	msg := make([]byte, 5000*pmacParallelChunk+17)
+
+	p := newPMAC(b, 5000)
+	p.Write(msg)
+	q := newPMAC(b, 64)
+	q.Write(msg)
+	if !bytes.Equal(p.Sum(nil), q.Sum(nil)) {
+		t.Error("5000 workers disagree with 64 workers")
+	}
+}
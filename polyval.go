@@ -0,0 +1,78 @@
+package sealion
+
+import "encoding/binary"
+
+// polyval implements the POLYVAL universal hash of RFC 8452 on top of a GHASH
+// style multiplication: POLYVAL(H, X_1, ..., X_n) is the byte reversal of
+// GHASH(mulX_GHASH(ByteReverse(H)), ByteReverse(X_1), ..., ByteReverse(X_n)).
+type polyval struct {
+	// h and s are held in the GHASH bit order, most significant word first
+	hHi, hLo uint64
+	sHi, sLo uint64
+}
+
+func newPolyval(key []byte) *polyval {
+	p := new(polyval)
+	var h [BlockSize]byte
+	reverseBlock(&h, key)
+	hi, lo := binary.BigEndian.Uint64(h[:8]), binary.BigEndian.Uint64(h[8:])
+
+	// mulX_GHASH
+	mask := -(lo & 1)
+	lo = lo>>1 | hi<<63
+	hi = hi>>1 ^ (0xe1<<56)&mask
+
+	p.hHi, p.hLo = hi, lo
+	return p
+}
+
+// update absorbs src, zero padding the final partial block if there is one.
+func (p *polyval) update(src []byte) {
+	for len(src) > 0 {
+		var block, x [BlockSize]byte
+		n := copy(block[:], src)
+		reverseBlock(&x, block[:])
+		p.sHi ^= binary.BigEndian.Uint64(x[:8])
+		p.sLo ^= binary.BigEndian.Uint64(x[8:])
+		p.sHi, p.sLo = ghashMul(p.sHi, p.sLo, p.hHi, p.hLo)
+		src = src[n:]
+	}
+}
+
+// sum returns the current POLYVAL value.
+func (p *polyval) sum() [BlockSize]byte {
+	var x, out [BlockSize]byte
+	binary.BigEndian.PutUint64(x[:8], p.sHi)
+	binary.BigEndian.PutUint64(x[8:], p.sLo)
+	reverseBlock(&out, x[:])
+	return out
+}
+
+// ghashMul multiplies x by y in GF(2^128) with the GCM bit ordering, in
+// constant time.
+func ghashMul(xHi, xLo, yHi, yLo uint64) (uint64, uint64) {
+	var zHi, zLo uint64
+	vHi, vLo := yHi, yLo
+	for i := 0; i < 128; i++ {
+		var bit uint64
+		if i < 64 {
+			bit = xHi >> (63 - uint(i)) & 1
+		} else {
+			bit = xLo >> (127 - uint(i)) & 1
+		}
+		mask := -bit
+		zHi ^= vHi & mask
+		zLo ^= vLo & mask
+
+		reduce := -(vLo & 1)
+		vLo = vLo>>1 | vHi<<63
+		vHi = vHi>>1 ^ (0xe1<<56)&reduce
+	}
+	return zHi, zLo
+}
+
+func reverseBlock(dst *[BlockSize]byte, src []byte) {
+	for i := 0; i < BlockSize; i++ {
+		dst[i] = src[BlockSize-1-i]
+	}
+}
@@ -0,0 +1,151 @@
+package sealion
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+)
+
+// maxSIVComponents is the number of associated data components S2V accepts
+// in addition to the plaintext (RFC 5297, Section 7).
+const maxSIVComponents = 126
+
+// SIV implements the deterministic authenticated encryption mode of RFC 5297
+// with SEA-Lion in place of AES. Its output is the synthetic IV followed by
+// the ciphertext.
+type SIV struct {
+	mac, ctr cipher.Block
+	k1, k2   [BlockSize]byte
+}
+
+// NewSIV returns a SIV instance keyed with key, which is the concatenation of
+// two SEA-Lion keys of equal length: the first is used for S2V and the second
+// for CTR encryption.
+func NewSIV(key []byte) (*SIV, error) {
+	switch len(key) {
+	case 32, 48, 64:
+		break
+	default:
+		return nil, KeySizeError(len(key))
+	}
+
+	mac, err := NewCipher(key[:len(key)/2])
+	if err != nil {
+		return nil, err
+	}
+	ctr, err := NewCipher(key[len(key)/2:])
+	if err != nil {
+		return nil, err
+	}
+	return newSIV(mac, ctr), nil
+}
+
+func newSIV(mac, ctr cipher.Block) *SIV {
+	s := &SIV{mac: mac, ctr: ctr}
+	s.k1, s.k2 = cmacSubkeys(mac)
+	return s
+}
+
+// NonceSize returns 0: SIV is deterministic by default. A non-empty nonce
+// passed to Seal or Open is used as the final associated data component.
+func (s *SIV) NonceSize() int {
+	return 0
+}
+
+func (s *SIV) Overhead() int {
+	return BlockSize
+}
+
+func (s *SIV) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	return s.SealComponents(dst, plaintext, aeadComponents(nonce, additionalData)...)
+}
+
+func (s *SIV) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	return s.OpenComponents(dst, ciphertext, aeadComponents(nonce, additionalData)...)
+}
+
+// SealComponents encrypts and authenticates plaintext together with every
+// associated data component in ad and appends the result to dst.
+func (s *SIV) SealComponents(dst, plaintext []byte, ad ...[]byte) []byte {
+	if len(ad) > maxSIVComponents {
+		panic("sealion: too many associated data components")
+	}
+
+	v := s.s2v(ad, plaintext)
+	ret, out := sliceForAppend(dst, BlockSize+len(plaintext))
+	if anyOverlap(out, plaintext) {
+		plaintext = append([]byte(nil), plaintext...)
+	}
+	copy(out, v[:])
+	s.xorKeyStream(out[BlockSize:], plaintext, &v)
+	return ret
+}
+
+// OpenComponents decrypts and authenticates ciphertext against the associated
+// data components in ad and appends the plaintext to dst.
+func (s *SIV) OpenComponents(dst, ciphertext []byte, ad ...[]byte) ([]byte, error) {
+	if len(ciphertext) < BlockSize || len(ad) > maxSIVComponents {
+		return nil, errOpen
+	}
+
+	var v [BlockSize]byte
+	copy(v[:], ciphertext)
+	ciphertext = ciphertext[BlockSize:]
+
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	if inexactOverlap(out, ciphertext) {
+		ciphertext = append([]byte(nil), ciphertext...)
+	}
+	s.xorKeyStream(out, ciphertext, &v)
+
+	expected := s.s2v(ad, out)
+	if subtle.ConstantTimeCompare(expected[:], v[:]) != 1 {
+		for i := range out {
+			out[i] = 0
+		}
+		return nil, errOpen
+	}
+	return ret, nil
+}
+
+// s2v computes the S2V PRF over the associated data components followed by
+// the final string sn.
+func (s *SIV) s2v(ad [][]byte, sn []byte) [BlockSize]byte {
+	var zero [BlockSize]byte
+	d := cmacSum(s.mac, &s.k1, &s.k2, zero[:])
+
+	for _, a := range ad {
+		dbl(&d)
+		m := cmacSum(s.mac, &s.k1, &s.k2, a)
+		subtle.XORBytes(d[:], d[:], m[:])
+	}
+
+	var t []byte
+	if len(sn) >= BlockSize {
+		// xorend: XOR D into the last block of Sn
+		t = make([]byte, len(sn))
+		copy(t, sn)
+		subtle.XORBytes(t[len(t)-BlockSize:], t[len(t)-BlockSize:], d[:])
+	} else {
+		dbl(&d)
+		t = d[:]
+		t[len(sn)] ^= 0x80
+		subtle.XORBytes(t, t, sn)
+	}
+	return cmacSum(s.mac, &s.k1, &s.k2, t)
+}
+
+func (s *SIV) xorKeyStream(dst, src []byte, v *[BlockSize]byte) {
+	// Clear the 31st and 63rd bits so implementations may use 64-bit counters
+	q := *v
+	q[8] &= 0x7f
+	q[12] &= 0x7f
+	cipher.NewCTR(s.ctr, q[:]).XORKeyStream(dst, src)
+}
+
+func aeadComponents(nonce, additionalData []byte) [][]byte {
+	ad := [][]byte{additionalData}
+	if len(nonce) > 0 {
+		ad = append(ad, nonce)
+	}
+	return ad
+}
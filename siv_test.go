@@ -0,0 +1,98 @@
+package sealion
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"testing"
+)
+
+func fromHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// RFC 5297, Appendix A, with AES in place of SEA-Lion
+var sivAESTests = []struct {
+	key, plaintext, ciphertext string
+	ad                         []string
+}{
+	{
+		"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff",
+		"112233445566778899aabbccddee",
+		"85632d07c6e8f37f950acd320a2ecc9340c02b9690c4dc04daef7f6afe5c",
+		[]string{"101112131415161718191a1b1c1d1e1f2021222324252627"},
+	},
+	{
+		"7f7e7d7c7b7a79787776757473727170404142434445464748494a4b4c4d4e4f",
+		"7468697320697320736f6d6520706c61696e7465787420746f20656e6372797074207573696e67205349562d414553",
+		"7bdb6e3b432667eb06f4d14bff2fbd0fcb900f2fddbe404326601965c889bf17dba77ceb094fa663b7a3f748ba8af829ea64ad544a272e9c485b62a3fd5c0d",
+		[]string{
+			"00112233445566778899aabbccddeeffdeaddadadeaddadaffeeddccbbaa99887766554433221100",
+			"102030405060708090a0",
+			"09f911029d74e35bd84156c5635688c0",
+		},
+	},
+}
+
+func TestSIVAES(t *testing.T) {
+	for i, tt := range sivAESTests {
+		key := fromHex(tt.key)
+		mac, _ := aes.NewCipher(key[:16])
+		ctr, _ := aes.NewCipher(key[16:])
+		s := newSIV(mac, ctr)
+
+		var ad [][]byte
+		for _, a := range tt.ad {
+			ad = append(ad, fromHex(a))
+		}
+		plaintext, want := fromHex(tt.plaintext), fromHex(tt.ciphertext)
+
+		ct := s.SealComponents(nil, plaintext, ad...)
+		if !bytes.Equal(ct, want) {
+			t.Errorf("#%d: got %x, want %x", i, ct, want)
+			continue
+		}
+		pt, err := s.OpenComponents(nil, ct, ad...)
+		if err != nil || !bytes.Equal(pt, plaintext) {
+			t.Errorf("#%d: Open failed: %v", i, err)
+		}
+	}
+}
+
+func TestSIV(t *testing.T) {
+	for _, size := range []int{32, 48, 64} {
+		s, err := NewSIV(make([]byte, size))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		plaintext := []byte("SEA-Lion in SIV mode")
+		ct := s.Seal(nil, []byte("nonce"), plaintext, []byte("ad"))
+		if len(ct) != len(plaintext)+s.Overhead() {
+			t.Errorf("key size %d: ciphertext length %d", size, len(ct))
+		}
+		if again := s.Seal(nil, []byte("nonce"), plaintext, []byte("ad")); !bytes.Equal(again, ct) {
+			t.Errorf("key size %d: SIV is not deterministic", size)
+		}
+		pt, err := s.Open(nil, []byte("nonce"), ct, []byte("ad"))
+		if err != nil || !bytes.Equal(pt, plaintext) {
+			t.Errorf("key size %d: Open failed: %v", size, err)
+		}
+
+		if _, err := s.Open(nil, []byte("other"), ct, []byte("ad")); err == nil {
+			t.Errorf("key size %d: opened with the wrong nonce", size)
+		}
+		ct[len(ct)-1] ^= 1
+		if _, err := s.Open(nil, []byte("nonce"), ct, []byte("ad")); err == nil {
+			t.Errorf("key size %d: opened a modified ciphertext", size)
+		}
+	}
+
+	if _, err := NewSIV(make([]byte, 16)); err == nil {
+		t.Error("NewSIV accepted a 16-byte key")
+	}
+}
@@ -0,0 +1,211 @@
+// Package sqlcrypt provides database/sql column types that are encrypted with
+// SEA-Lion on write and decrypted on read, using a keyring of SEA-Lion keys so
+// that keys can be rotated without rewriting existing rows.
+package sqlcrypt
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql/driver"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Sid-Sun/sealion"
+)
+
+// Stored value layout: version (1) || key id (4) || nonce || ciphertext. The
+// version and key id are authenticated as associated data.
+const (
+	version1  = 0x01
+	headerLen = 1 + 4
+)
+
+var (
+	// ErrNoKeyring is returned by the column types when no default keyring
+	// has been set.
+	ErrNoKeyring = errors.New("sqlcrypt: no keyring configured")
+	// ErrUnknownKey is returned when a value was encrypted under a key id that
+	// is not in the keyring.
+	ErrUnknownKey = errors.New("sqlcrypt: unknown key id")
+	// ErrDecrypt is returned when a stored value is malformed or fails
+	// authentication.
+	ErrDecrypt = errors.New("sqlcrypt: decryption failed")
+)
+
+// Keyring holds SEA-Lion keys by id. New values are encrypted with
+// SEA-Lion GCM-SIV under the primary key; values are decrypted with the key
+// whose id they carry. It is safe for concurrent use.
+type Keyring struct {
+	mu      sync.RWMutex
+	keys    map[uint32]cipher.AEAD
+	primary uint32
+}
+
+// NewKeyring returns a keyring whose primary key is key, with the given id.
+func NewKeyring(id uint32, key []byte) (*Keyring, error) {
+	k := &Keyring{keys: make(map[uint32]cipher.AEAD)}
+	if err := k.Add(id, key); err != nil {
+		return nil, err
+	}
+	k.primary = id
+	return k, nil
+}
+
+// Add adds key under id, for example to keep decrypting values written before
+// a rotation. It fails if id is already in use.
+func (k *Keyring) Add(id uint32, key []byte) error {
+	aead, err := sealion.NewGCMSIV(key)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[id]; ok {
+		return fmt.Errorf("sqlcrypt: key id %d already in keyring", id)
+	}
+	k.keys[id] = aead
+	return nil
+}
+
+// SetPrimary makes the key with id the one new values are encrypted under.
+func (k *Keyring) SetPrimary(id uint32) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[id]; !ok {
+		return ErrUnknownKey
+	}
+	k.primary = id
+	return nil
+}
+
+// Encrypt encrypts plaintext under the primary key.
+func (k *Keyring) Encrypt(plaintext []byte) ([]byte, error) {
+	k.mu.RLock()
+	id, aead := k.primary, k.keys[k.primary]
+	k.mu.RUnlock()
+
+	out := make([]byte, headerLen+aead.NonceSize(), headerLen+aead.NonceSize()+len(plaintext)+aead.Overhead())
+	out[0] = version1
+	binary.BigEndian.PutUint32(out[1:headerLen], id)
+	nonce := out[headerLen:]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(out, nonce, plaintext, out[:headerLen]), nil
+}
+
+// Decrypt decrypts a value produced by Encrypt with any key in the keyring.
+func (k *Keyring) Decrypt(ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < headerLen || ciphertext[0] != version1 {
+		return nil, ErrDecrypt
+	}
+	id := binary.BigEndian.Uint32(ciphertext[1:headerLen])
+
+	k.mu.RLock()
+	aead, ok := k.keys[id]
+	k.mu.RUnlock()
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	rest := ciphertext[headerLen:]
+	if len(rest) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrDecrypt
+	}
+	plaintext, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], ciphertext[:headerLen])
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+var defaultKeyring atomic.Pointer[Keyring]
+
+// SetKeyring sets the keyring used by EncryptedString and EncryptedBytes.
+func SetKeyring(k *Keyring) {
+	defaultKeyring.Store(k)
+}
+
+func keyring() (*Keyring, error) {
+	k := defaultKeyring.Load()
+	if k == nil {
+		return nil, ErrNoKeyring
+	}
+	return k, nil
+}
+
+// EncryptedString is a string column stored encrypted under the keyring set
+// with SetKeyring. It cannot hold NULL.
+type EncryptedString string
+
+// Value implements driver.Valuer.
+func (s EncryptedString) Value() (driver.Value, error) {
+	k, err := keyring()
+	if err != nil {
+		return nil, err
+	}
+	return k.Encrypt([]byte(s))
+}
+
+// Scan implements sql.Scanner.
+func (s *EncryptedString) Scan(src any) error {
+	if src == nil {
+		return errors.New("sqlcrypt: cannot scan NULL into EncryptedString")
+	}
+	plaintext, err := decryptColumn(src)
+	if err != nil {
+		return err
+	}
+	*s = EncryptedString(plaintext)
+	return nil
+}
+
+// EncryptedBytes is a binary column stored encrypted under the keyring set
+// with SetKeyring. A nil EncryptedBytes is stored as NULL.
+type EncryptedBytes []byte
+
+// Value implements driver.Valuer.
+func (b EncryptedBytes) Value() (driver.Value, error) {
+	if b == nil {
+		return nil, nil
+	}
+	k, err := keyring()
+	if err != nil {
+		return nil, err
+	}
+	return k.Encrypt(b)
+}
+
+// Scan implements sql.Scanner.
+func (b *EncryptedBytes) Scan(src any) error {
+	if src == nil {
+		*b = nil
+		return nil
+	}
+	plaintext, err := decryptColumn(src)
+	if err != nil {
+		return err
+	}
+	// Keep an empty value non-nil so it is not written back as NULL
+	*b = append(EncryptedBytes{}, plaintext...)
+	return nil
+}
+
+func decryptColumn(src any) ([]byte, error) {
+	k, err := keyring()
+	if err != nil {
+		return nil, err
+	}
+	switch v := src.(type) {
+	case []byte:
+		return k.Decrypt(v)
+	case string:
+		return k.Decrypt([]byte(v))
+	default:
+		return nil, fmt.Errorf("sqlcrypt: cannot scan %T into an encrypted column", src)
+	}
+}
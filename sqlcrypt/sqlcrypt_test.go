@@ -0,0 +1,135 @@
+package sqlcrypt
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+var (
+	_ sql.Scanner   = (*EncryptedString)(nil)
+	_ driver.Valuer = EncryptedString("")
+	_ sql.Scanner   = (*EncryptedBytes)(nil)
+	_ driver.Valuer = EncryptedBytes(nil)
+)
+
+func useKeyring(t *testing.T, k *Keyring) {
+	t.Helper()
+	SetKeyring(k)
+	t.Cleanup(func() { SetKeyring(nil) })
+}
+
+func TestKeyring(t *testing.T) {
+	k, err := NewKeyring(1, make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old, err := k.Encrypt([]byte("written under key 1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rotate: new values use key 2, old values still decrypt
+	if err := k.Add(2, bytes.Repeat([]byte{2}, 16)); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.SetPrimary(2); err != nil {
+		t.Fatal(err)
+	}
+	fresh, _ := k.Encrypt([]byte("written under key 2"))
+	if fresh[4] != 2 {
+		t.Errorf("new value carries key id %x", fresh[1:headerLen])
+	}
+	for _, ct := range [][]byte{old, fresh} {
+		if _, err := k.Decrypt(ct); err != nil {
+			t.Errorf("Decrypt: %v", err)
+		}
+	}
+
+	if err := k.Add(2, make([]byte, 16)); err == nil {
+		t.Error("added a duplicate key id")
+	}
+	if err := k.SetPrimary(3); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("SetPrimary of an unknown id gave %v", err)
+	}
+	if _, err := NewKeyring(1, make([]byte, 10)); err == nil {
+		t.Error("accepted a 10-byte key")
+	}
+
+	other, _ := NewKeyring(3, make([]byte, 32))
+	if _, err := other.Decrypt(old); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("value under an unknown key id gave %v", err)
+	}
+	for i := range old {
+		modified := append([]byte(nil), old...)
+		modified[i] ^= 1
+		if _, err := k.Decrypt(modified); err == nil {
+			t.Errorf("value modified at byte %d was accepted", i)
+		}
+	}
+}
+
+func TestEncryptedString(t *testing.T) {
+	var s EncryptedString
+	if err := s.Scan([]byte{1}); !errors.Is(err, ErrNoKeyring) {
+		t.Errorf("Scan without a keyring gave %v", err)
+	}
+	if _, err := EncryptedString("x").Value(); !errors.Is(err, ErrNoKeyring) {
+		t.Errorf("Value without a keyring gave %v", err)
+	}
+
+	k, _ := NewKeyring(1, make([]byte, 32))
+	useKeyring(t, k)
+
+	v, err := EncryptedString("secret").Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, ok := v.([]byte)
+	if !ok || bytes.Contains(ct, []byte("secret")) {
+		t.Fatalf("Value returned %#v", v)
+	}
+	for _, src := range []any{ct, string(ct)} {
+		s = ""
+		if err := s.Scan(src); err != nil || s != "secret" {
+			t.Errorf("Scan(%T) gave %q, %v", src, s, err)
+		}
+	}
+
+	if err := s.Scan(nil); err == nil {
+		t.Error("scanned NULL into an EncryptedString")
+	}
+	if err := s.Scan(42); err == nil {
+		t.Error("scanned an int")
+	}
+}
+
+func TestEncryptedBytes(t *testing.T) {
+	k, _ := NewKeyring(1, make([]byte, 32))
+	useKeyring(t, k)
+
+	for _, in := range []EncryptedBytes{{1, 2, 3}, {}} {
+		v, err := in.Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out EncryptedBytes
+		if err := out.Scan(v); err != nil {
+			t.Fatal(err)
+		}
+		// An empty value must stay non-nil so it is not written back as NULL
+		if out == nil || !bytes.Equal(out, in) {
+			t.Errorf("Scan of %x gave %#v", in, out)
+		}
+	}
+
+	if v, err := EncryptedBytes(nil).Value(); v != nil || err != nil {
+		t.Errorf("nil EncryptedBytes gave %#v, %v", v, err)
+	}
+	out := EncryptedBytes{1}
+	if err := out.Scan(nil); err != nil || out != nil {
+		t.Errorf("Scan of NULL gave %#v, %v", out, err)
+	}
+}
@@ -0,0 +1,218 @@
+package sealion
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+const (
+	// Plaintext bytes per STREAM segment
+	streamChunkSize = 64 * 1024
+	// Nonce suffix: 32-bit segment counter and last segment flag
+	streamNonceSuffix = 5
+)
+
+var (
+	errStreamClosed    = errors.New("sealion: write to closed STREAM writer")
+	errStreamTooLong   = errors.New("sealion: STREAM segment counter overflow")
+	errStreamTruncated = errors.New("sealion: STREAM truncated or corrupted")
+)
+
+// streamNonce builds the segment nonces prefix || [i]_32 || [last]_8 of the
+// STREAM online AEAD construction.
+type streamNonce struct {
+	nonce   []byte
+	counter uint64
+}
+
+func newStreamNonce(aead cipher.AEAD, prefix []byte) (*streamNonce, error) {
+	if len(prefix)+streamNonceSuffix != aead.NonceSize() {
+		return nil, errors.New("sealion: STREAM nonce prefix must be 5 bytes shorter than the AEAD nonce")
+	}
+	n := &streamNonce{nonce: make([]byte, aead.NonceSize())}
+	copy(n.nonce, prefix)
+	return n, nil
+}
+
+// next returns the nonce of the next segment.
+func (n *streamNonce) next(last bool) ([]byte, error) {
+	if n.counter > math.MaxUint32 {
+		return nil, errStreamTooLong
+	}
+	suffix := n.nonce[len(n.nonce)-streamNonceSuffix:]
+	binary.BigEndian.PutUint32(suffix, uint32(n.counter))
+	suffix[4] = 0
+	if last {
+		suffix[4] = 1
+	}
+	n.counter++
+	return n.nonce, nil
+}
+
+type streamWriter struct {
+	w     io.Writer
+	aead  cipher.AEAD
+	nonce *streamNonce
+	buf   []byte
+	out   []byte
+	err   error
+}
+
+// NewStreamWriter returns an io.WriteCloser that splits everything written to
+// it into segments and seals each with aead following the STREAM
+// construction, so the result can be authenticated incrementally and any
+// truncation, reordering or removal of segments is detected. prefix must be
+// aead.NonceSize()-5 bytes, unique per stream under the same key. Close must
+// be called to seal the final segment; it does not close w.
+func NewStreamWriter(w io.Writer, aead cipher.AEAD, prefix []byte) (io.WriteCloser, error) {
+	nonce, err := newStreamNonce(aead, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return &streamWriter{
+		w:     w,
+		aead:  aead,
+		nonce: nonce,
+		buf:   make([]byte, 0, streamChunkSize),
+		out:   make([]byte, 0, streamChunkSize+aead.Overhead()),
+	}, nil
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+
+	written := 0
+	for len(p) > 0 {
+		// A full segment is only sealed once more data shows it is not the last
+		if len(s.buf) == streamChunkSize {
+			if err := s.flush(false); err != nil {
+				return written, err
+			}
+		}
+		n := copy(s.buf[len(s.buf):cap(s.buf)], p)
+		s.buf = s.buf[:len(s.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+func (s *streamWriter) Close() error {
+	if s.err != nil {
+		if s.err == errStreamClosed {
+			return nil
+		}
+		return s.err
+	}
+	if err := s.flush(true); err != nil {
+		return err
+	}
+	s.err = errStreamClosed
+	return nil
+}
+
+func (s *streamWriter) flush(last bool) error {
+	nonce, err := s.nonce.next(last)
+	if err != nil {
+		s.err = err
+		return err
+	}
+	s.out = s.aead.Seal(s.out[:0], nonce, s.buf, nil)
+	if _, err := s.w.Write(s.out); err != nil {
+		s.err = err
+		return err
+	}
+	s.buf = s.buf[:0]
+	return nil
+}
+
+type streamReader struct {
+	r     io.Reader
+	aead  cipher.AEAD
+	nonce *streamNonce
+	in    []byte // ciphertext read ahead, up to one segment and one byte
+	plain []byte
+	buf   []byte // decrypted plaintext not yet returned
+	done  bool
+	err   error
+}
+
+// NewStreamReader returns an io.Reader that opens the segments produced by
+// NewStreamWriter with the same aead and prefix. It returns an error instead
+// of io.EOF if the stream was truncated or tampered with; plaintext is only
+// released one authenticated segment at a time.
+func NewStreamReader(r io.Reader, aead cipher.AEAD, prefix []byte) (io.Reader, error) {
+	nonce, err := newStreamNonce(aead, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return &streamReader{
+		r:     r,
+		aead:  aead,
+		nonce: nonce,
+		in:    make([]byte, 0, streamChunkSize+aead.Overhead()+1),
+		plain: make([]byte, 0, streamChunkSize),
+	}, nil
+}
+
+func (s *streamReader) Read(p []byte) (int, error) {
+	for len(s.buf) == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+		if s.done {
+			return 0, io.EOF
+		}
+		s.err = s.next()
+	}
+
+	n := copy(p, s.buf)
+	s.buf = s.buf[n:]
+	return n, nil
+}
+
+// next reads and opens the following segment. A segment is the last one
+// exactly when the underlying reader ends before one more byte follows it.
+func (s *streamReader) next() error {
+	segment := streamChunkSize + s.aead.Overhead()
+	n, err := io.ReadFull(s.r, s.in[len(s.in):segment+1])
+	s.in = s.in[:len(s.in)+n]
+
+	last := false
+	switch err {
+	case nil:
+	case io.EOF, io.ErrUnexpectedEOF:
+		last = true
+	default:
+		return err
+	}
+
+	ciphertext := s.in
+	if !last {
+		ciphertext = s.in[:segment]
+	}
+	nonce, err := s.nonce.next(last)
+	if err != nil {
+		return err
+	}
+	plaintext, err := s.aead.Open(s.plain[:0], nonce, ciphertext, nil)
+	if err != nil {
+		return errStreamTruncated
+	}
+	s.buf = plaintext
+
+	if last {
+		s.done = true
+		s.in = s.in[:0]
+	} else {
+		// Keep the read-ahead byte at the start of the buffer
+		s.in[0] = s.in[segment]
+		s.in = s.in[:1]
+	}
+	return nil
+}
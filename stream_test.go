@@ -0,0 +1,127 @@
+package sealion
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"io"
+	"testing"
+)
+
+func newTestStreamAEAD(t *testing.T) (cipher.AEAD, []byte) {
+	t.Helper()
+	b, _ := NewCipher(make([]byte, 16))
+	aead, err := NewOCB(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return aead, []byte("prefix!")
+}
+
+func sealStream(t *testing.T, aead cipher.AEAD, prefix, plaintext []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewStreamWriter(&buf, aead, prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Uneven writes cross segment boundaries
+	for p := plaintext; len(p) > 0; {
+		n := min(1000, len(p))
+		if _, err := w.Write(p[:n]); err != nil {
+			t.Fatal(err)
+		}
+		p = p[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func openStream(aead cipher.AEAD, prefix, ciphertext []byte) ([]byte, error) {
+	r, err := NewStreamReader(bytes.NewReader(ciphertext), aead, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestStream(t *testing.T) {
+	aead, prefix := newTestStreamAEAD(t)
+	for _, n := range []int{0, 1, streamChunkSize - 1, streamChunkSize, streamChunkSize + 1, 3 * streamChunkSize} {
+		plaintext := make([]byte, n)
+		for i := range plaintext {
+			plaintext[i] = byte(i)
+		}
+		ct := sealStream(t, aead, prefix, plaintext)
+		// A full last segment is not followed by an empty one
+		segments := max(1, (n+streamChunkSize-1)/streamChunkSize)
+		if len(ct) != n+segments*aead.Overhead() {
+			t.Errorf("length %d: ciphertext length %d", n, len(ct))
+		}
+
+		pt, err := openStream(aead, prefix, ct)
+		if err != nil || !bytes.Equal(pt, plaintext) {
+			t.Errorf("length %d: round trip failed: %v", n, err)
+		}
+		if _, err := openStream(aead, []byte("other!!"), ct); err == nil {
+			t.Errorf("length %d: opened with the wrong prefix", n)
+		}
+	}
+}
+
+func TestStreamTruncation(t *testing.T) {
+	aead, prefix := newTestStreamAEAD(t)
+	plaintext := make([]byte, 3*streamChunkSize+10)
+	ct := sealStream(t, aead, prefix, plaintext)
+	segment := streamChunkSize + aead.Overhead()
+
+	for _, n := range []int{0, 1, segment, 2 * segment, 2*segment + 5, len(ct) - 1} {
+		if _, err := openStream(aead, prefix, ct[:n]); err == nil {
+			t.Errorf("stream truncated to %d bytes was accepted", n)
+		}
+	}
+
+	// Swap the first two segments
+	reordered := append([]byte(nil), ct[segment:2*segment]...)
+	reordered = append(reordered, ct[:segment]...)
+	reordered = append(reordered, ct[2*segment:]...)
+	if _, err := openStream(aead, prefix, reordered); err == nil {
+		t.Error("reordered stream was accepted")
+	}
+
+	// Drop a middle segment
+	dropped := append(append([]byte(nil), ct[:segment]...), ct[2*segment:]...)
+	if _, err := openStream(aead, prefix, dropped); err == nil {
+		t.Error("stream with a missing segment was accepted")
+	}
+
+	tampered := append([]byte(nil), ct...)
+	tampered[segment+3] ^= 1
+	r, _ := NewStreamReader(bytes.NewReader(tampered), aead, prefix)
+	got, err := io.ReadAll(r)
+	if err == nil {
+		t.Error("tampered stream was accepted")
+	}
+	if len(got) != streamChunkSize {
+		t.Errorf("released %d bytes, want only the first segment", len(got))
+	}
+}
+
+func TestStreamWriterClose(t *testing.T) {
+	aead, prefix := newTestStreamAEAD(t)
+	w, _ := NewStreamWriter(io.Discard, aead, prefix)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+	if _, err := w.Write([]byte("x")); err == nil {
+		t.Error("Write after Close succeeded")
+	}
+
+	if _, err := NewStreamWriter(io.Discard, aead, make([]byte, aead.NonceSize())); err == nil {
+		t.Error("accepted a prefix as long as the nonce")
+	}
+}
@@ -0,0 +1,152 @@
+package sealion
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+)
+
+// TweakSize is the tweak length of the tweakable block ciphers in this package
+const TweakSize = 16
+
+// TweakableBlock is a block cipher whose permutation is additionally selected
+// by a public tweak, so every tweak behaves like an independent key.
+type TweakableBlock interface {
+	// BlockSize returns the cipher's block size.
+	BlockSize() int
+
+	// TweakSize returns the length of the tweak.
+	TweakSize() int
+
+	// Encrypt encrypts the first block in src into dst under tweak.
+	// Dst and src must overlap entirely or not at all.
+	Encrypt(dst, src, tweak []byte)
+
+	// Decrypt decrypts the first block in src into dst under tweak.
+	// Dst and src must overlap entirely or not at all.
+	Decrypt(dst, src, tweak []byte)
+}
+
+// xex implements Rogaway's XEX construction with mask 2·E_K(T).
+type xex struct {
+	b cipher.Block
+}
+
+// NewXEX returns a TweakableBlock built from SEA-Lion with the XEX
+// construction: C = E_K(P xor Δ) xor Δ with Δ = 2·E_K(T).
+func NewXEX(key []byte) (TweakableBlock, error) {
+	b, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &xex{b: b}, nil
+}
+
+func (x *xex) BlockSize() int {
+	return BlockSize
+}
+
+func (x *xex) TweakSize() int {
+	return TweakSize
+}
+
+func (x *xex) Encrypt(dst, src, tweak []byte) {
+	delta := x.mask(tweak)
+	tweakCrypt(x.b, dst, src, &delta, false)
+}
+
+func (x *xex) Decrypt(dst, src, tweak []byte) {
+	delta := x.mask(tweak)
+	tweakCrypt(x.b, dst, src, &delta, true)
+}
+
+func (x *xex) mask(tweak []byte) [BlockSize]byte {
+	if len(tweak) != TweakSize {
+		panic("sealion: invalid tweak length")
+	}
+	var delta [BlockSize]byte
+	x.b.Encrypt(delta[:], tweak)
+	dbl(&delta)
+	return delta
+}
+
+// lrw implements the LRW construction of Liskov, Rivest and Wagner.
+type lrw struct {
+	b cipher.Block
+	k [BlockSize]byte
+}
+
+// NewLRW returns a TweakableBlock built from SEA-Lion with the LRW
+// construction: C = E_K1(P xor K2⊗T) xor K2⊗T. key is a SEA-Lion key K1
+// followed by the 16-byte multiplication key K2.
+func NewLRW(key []byte) (TweakableBlock, error) {
+	switch len(key) {
+	case 16 + TweakSize, 24 + TweakSize, 32 + TweakSize:
+		break
+	default:
+		return nil, KeySizeError(len(key))
+	}
+
+	b, err := NewCipher(key[:len(key)-TweakSize])
+	if err != nil {
+		return nil, err
+	}
+	l := &lrw{b: b}
+	copy(l.k[:], key[len(key)-TweakSize:])
+	return l, nil
+}
+
+func (l *lrw) BlockSize() int {
+	return BlockSize
+}
+
+func (l *lrw) TweakSize() int {
+	return TweakSize
+}
+
+func (l *lrw) Encrypt(dst, src, tweak []byte) {
+	delta := l.mask(tweak)
+	tweakCrypt(l.b, dst, src, &delta, false)
+}
+
+func (l *lrw) Decrypt(dst, src, tweak []byte) {
+	delta := l.mask(tweak)
+	tweakCrypt(l.b, dst, src, &delta, true)
+}
+
+// mask computes K2⊗T in GF(2^128) by Horner's rule over the bits of T, most
+// significant first, in constant time.
+func (l *lrw) mask(tweak []byte) [BlockSize]byte {
+	if len(tweak) != TweakSize {
+		panic("sealion: invalid tweak length")
+	}
+	var delta, term [BlockSize]byte
+	for _, t := range tweak {
+		for bit := 7; bit >= 0; bit-- {
+			dbl(&delta)
+			m := -(t >> uint(bit) & 1)
+			for i := range term {
+				term[i] = l.k[i] & m
+			}
+			subtle.XORBytes(delta[:], delta[:], term[:])
+		}
+	}
+	return delta
+}
+
+// tweakCrypt computes E_K(src xor delta) xor delta, or its inverse.
+func tweakCrypt(b cipher.Block, dst, src []byte, delta *[BlockSize]byte, decrypt bool) {
+	if len(src) < BlockSize {
+		panic("sealion: input not full block")
+	}
+	if len(dst) < BlockSize {
+		panic("sealion: output not full block")
+	}
+	var t [BlockSize]byte
+	subtle.XORBytes(t[:], src[:BlockSize], delta[:])
+	if decrypt {
+		b.Decrypt(t[:], t[:])
+	} else {
+		b.Encrypt(t[:], t[:])
+	}
+	subtle.XORBytes(dst[:BlockSize], t[:], delta[:])
+}
@@ -0,0 +1,112 @@
+package sealion
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"testing"
+)
+
+func testTweakableBlock(t *testing.T, tb TweakableBlock) {
+	t.Helper()
+	if tb.BlockSize() != BlockSize || tb.TweakSize() != TweakSize {
+		t.Fatalf("BlockSize %d, TweakSize %d", tb.BlockSize(), tb.TweakSize())
+	}
+
+	src := []byte("sixteen byte msg")
+	tweak := make([]byte, TweakSize)
+	ct := make([]byte, BlockSize)
+	tb.Encrypt(ct, src, tweak)
+
+	pt := make([]byte, BlockSize)
+	tb.Decrypt(pt, ct, tweak)
+	if !bytes.Equal(pt, src) {
+		t.Errorf("round trip failed")
+	}
+
+	buf := append([]byte(nil), src...)
+	tb.Encrypt(buf, buf, tweak)
+	if !bytes.Equal(buf, ct) {
+		t.Errorf("in-place encryption differs")
+	}
+
+	for i := 0; i < TweakSize; i++ {
+		other := make([]byte, TweakSize)
+		other[i] = 0x40
+		out := make([]byte, BlockSize)
+		tb.Encrypt(out, src, other)
+		if bytes.Equal(out, ct) {
+			t.Errorf("tweak byte %d has no effect", i)
+		}
+	}
+}
+
+func TestXEX(t *testing.T) {
+	key := make([]byte, 16)
+	tb, err := NewXEX(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testTweakableBlock(t, tb)
+
+	// C = E_K(P xor Δ) xor Δ with Δ = 2·E_K(T)
+	b, _ := NewCipher(key)
+	tweak, src := []byte("tweak tweak twea"), []byte("plaintext block!")
+	var delta [BlockSize]byte
+	b.Encrypt(delta[:], tweak)
+	dbl(&delta)
+	want := make([]byte, BlockSize)
+	subtle.XORBytes(want, src, delta[:])
+	b.Encrypt(want, want)
+	subtle.XORBytes(want, want, delta[:])
+
+	got := make([]byte, BlockSize)
+	tb.Encrypt(got, src, tweak)
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+}
+
+func TestLRW(t *testing.T) {
+	for _, size := range []int{16, 24, 32} {
+		key := make([]byte, size+TweakSize)
+		copy(key[size:], "multiplier key!!")
+		tb, err := NewLRW(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testTweakableBlock(t, tb)
+	}
+
+	if _, err := NewLRW(make([]byte, 16)); err == nil {
+		t.Error("accepted a key without the multiplication key")
+	}
+}
+
+func TestLRWMask(t *testing.T) {
+	l := &lrw{}
+	copy(l.k[:], "multiplier key!!")
+
+	// K2⊗1 = K2 and K2⊗x = 2·K2
+	one := make([]byte, TweakSize)
+	one[TweakSize-1] = 1
+	if got := l.mask(one); got != l.k {
+		t.Errorf("K2⊗1 = %x, want %x", got, l.k)
+	}
+	x := make([]byte, TweakSize)
+	x[TweakSize-1] = 2
+	want := l.k
+	dbl(&want)
+	if got := l.mask(x); got != want {
+		t.Errorf("K2⊗x = %x, want %x", got, want)
+	}
+
+	// Multiplication distributes over addition
+	a, b := []byte("first tweak 0001"), []byte("second tweak 002")
+	sum := make([]byte, TweakSize)
+	subtle.XORBytes(sum, a, b)
+	ma, mb := l.mask(a), l.mask(b)
+	subtle.XORBytes(ma[:], ma[:], mb[:])
+	if got := l.mask(sum); got != ma {
+		t.Errorf("K2⊗(a+b) = %x, want %x", got, ma)
+	}
+}
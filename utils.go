@@ -1,13 +1,29 @@
 package sealion
 
-func pht8(a, b *byte) (byte, byte) {
-	temp := (*a + *b) % byte(255)
-	return temp, (temp + *b) % byte(255)
+import "unsafe"
+
+// pht8Lanes runs the byte PHT on four pairs at once, one pair per 16 bit
+// lane with a in the low byte of a's lane and b in the low byte of b's.
+func pht8Lanes(a, b uint64) (uint64, uint64) {
+	temp := mod255Lanes(a + b)
+	return temp, mod255Lanes(temp + b)
 }
 
 func pht16(a, b *uint16) (uint16, uint16) {
-	temp := (*a + *b) % uint16(65535)
-	return temp, (temp + *b) % uint16(65535)
+	temp := mod65535(*a + *b)
+	return temp, mod65535(temp + *b)
+}
+
+// mod255Lanes reduces the sum in every 16 bit lane to a byte and computes it
+// % 255 without a division: only 255 itself wraps to 0
+func mod255Lanes(x uint64) uint64 {
+	x &= 0x00ff00ff00ff00ff
+	return x ^ ((x+0x0001000100010001)>>8&0x0001000100010001)*0xff
+}
+
+// mod65535 computes x % 65535 without a division: only 65535 itself wraps to 0
+func mod65535(x uint16) uint16 {
+	return x ^ (0xffff & -uint16((uint32(x)+1)>>16))
 }
 
 func concatenate8ToGet16(a, b uint8) uint16 {
@@ -22,16 +38,52 @@ func concatenate32(a, b *uint32) uint64 {
 	return (uint64(*a) << 32) | uint64(*b)
 }
 
-func rotate16RightBy4(x *uint16) {
-	*x = (*x)>>4 | (*x)<<(12)
-}
-
-func shift16ToGet8(x *uint16, ind uint8) uint8 { //
-	// Indexing must start at 1
-	return uint8((*x << ((ind - 1) * 8)) >> (8))
+// rotate16LanesRightBy4 rotates each of the four 16 bit words of x right by 4.
+func rotate16LanesRightBy4(x uint64) uint64 {
+	return x>>4&0x0fff0fff0fff0fff | x<<12&0xf000f000f000f000
 }
 
 func shift32ToGet16(x *uint32, ind uint8) uint16 {
 	// Indexing must start at 1
 	return uint16((*x << ((ind - 1) * 16)) >> 16)
 }
+
+// dbl multiplies b by x in GF(2^128) using the big-endian convention of
+// CMAC, SIV and OCB (reduction polynomial x^128 + x^7 + x^2 + x + 1).
+func dbl(b *[BlockSize]byte) {
+	carry := b[0] >> 7
+	for i := 0; i < BlockSize-1; i++ {
+		b[i] = b[i]<<1 | b[i+1]>>7
+	}
+	b[BlockSize-1] = b[BlockSize-1]<<1 ^ (0x87 & -carry)
+}
+
+// sliceForAppend takes a slice and a requested number of bytes. It returns a
+// slice with the contents of the given slice followed by that many bytes and a
+// second slice that aliases into it and contains only the extra bytes.
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
+
+// anyOverlap reports whether x and y share memory at any index.
+func anyOverlap(x, y []byte) bool {
+	return len(x) > 0 && len(y) > 0 &&
+		uintptr(unsafe.Pointer(&x[0])) <= uintptr(unsafe.Pointer(&y[len(y)-1])) &&
+		uintptr(unsafe.Pointer(&y[0])) <= uintptr(unsafe.Pointer(&x[len(x)-1]))
+}
+
+// inexactOverlap reports whether x and y share memory at any non-corresponding
+// index, which makes in-place processing unsafe.
+func inexactOverlap(x, y []byte) bool {
+	if len(x) == 0 || len(y) == 0 || &x[0] == &y[0] {
+		return false
+	}
+	return anyOverlap(x, y)
+}
@@ -0,0 +1,122 @@
+package sealion
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+)
+
+// XTS implements the XTS-AES tweakable storage encryption mode of IEEE 1619
+// with SEA-Lion in place of AES. Sectors need not be a multiple of the block
+// size; a partial final block is handled with ciphertext stealing.
+type XTS struct {
+	k1, k2 cipher.Block
+}
+
+// NewXTS returns an XTS instance keyed with key, which is the concatenation of
+// two SEA-Lion keys of equal length: the first encrypts the data and the
+// second encrypts the sector number into the tweak.
+func NewXTS(key []byte) (*XTS, error) {
+	switch len(key) {
+	case 32, 48, 64:
+		break
+	default:
+		return nil, KeySizeError(len(key))
+	}
+
+	k1, err := NewCipher(key[:len(key)/2])
+	if err != nil {
+		return nil, err
+	}
+	k2, err := NewCipher(key[len(key)/2:])
+	if err != nil {
+		return nil, err
+	}
+	return &XTS{k1: k1, k2: k2}, nil
+}
+
+// EncryptSector encrypts a sector of at least one block from src into dst.
+// dst and src must overlap entirely or not at all.
+func (x *XTS) EncryptSector(dst, src []byte, sectorNum uint64) {
+	x.crypt(dst, src, sectorNum, false)
+}
+
+// DecryptSector decrypts a sector of at least one block from src into dst.
+// dst and src must overlap entirely or not at all.
+func (x *XTS) DecryptSector(dst, src []byte, sectorNum uint64) {
+	x.crypt(dst, src, sectorNum, true)
+}
+
+func (x *XTS) crypt(dst, src []byte, sectorNum uint64, decrypt bool) {
+	if len(src) < BlockSize {
+		panic("sealion: XTS sector smaller than one block")
+	}
+	if len(dst) < len(src) {
+		panic("sealion: output smaller than input")
+	}
+	if inexactOverlap(dst[:len(src)], src) {
+		panic("sealion: invalid buffer overlap")
+	}
+
+	var tweak [BlockSize]byte
+	binary.LittleEndian.PutUint64(tweak[:8], sectorNum)
+	x.k2.Encrypt(tweak[:], tweak[:])
+
+	// With a partial final block the last full block is left for stealing
+	full := len(src) / BlockSize
+	tail := len(src) % BlockSize
+	if tail != 0 {
+		full--
+	}
+
+	for i := 0; i < full; i++ {
+		x.cryptBlock(dst[i*BlockSize:], src[i*BlockSize:], &tweak, decrypt)
+		mulAlpha(&tweak)
+	}
+	if tail == 0 {
+		return
+	}
+
+	last := dst[full*BlockSize:]
+	src = src[full*BlockSize:]
+	var cc, pp [BlockSize]byte
+	if decrypt {
+		// The last full ciphertext block was produced under the final tweak
+		next := tweak
+		mulAlpha(&next)
+		x.cryptBlock(pp[:], src, &next, true)
+		copy(cc[:], src[BlockSize:])
+		copy(cc[tail:], pp[tail:])
+		copy(last[BlockSize:], pp[:tail])
+		x.cryptBlock(last, cc[:], &tweak, true)
+	} else {
+		x.cryptBlock(cc[:], src, &tweak, false)
+		mulAlpha(&tweak)
+		copy(pp[:], src[BlockSize:])
+		copy(pp[tail:], cc[tail:])
+		copy(last[BlockSize:], cc[:tail])
+		x.cryptBlock(last, pp[:], &tweak, false)
+	}
+}
+
+// cryptBlock computes C = E_K1(P xor T) xor T, or its inverse.
+func (x *XTS) cryptBlock(dst, src []byte, tweak *[BlockSize]byte, decrypt bool) {
+	var t [BlockSize]byte
+	subtle.XORBytes(t[:], src[:BlockSize], tweak[:])
+	if decrypt {
+		x.k1.Decrypt(t[:], t[:])
+	} else {
+		x.k1.Encrypt(t[:], t[:])
+	}
+	subtle.XORBytes(dst[:BlockSize], t[:], tweak[:])
+}
+
+// mulAlpha multiplies the tweak by the primitive element alpha of GF(2^128)
+// in the little-endian convention of IEEE 1619.
+func mulAlpha(t *[BlockSize]byte) {
+	carry := t[BlockSize-1] >> 7
+	for i := BlockSize - 1; i > 0; i-- {
+		t[i] = t[i]<<1 | t[i-1]>>7
+	}
+	t[0] = t[0]<<1 ^ (0x87 & -carry)
+}
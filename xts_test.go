@@ -0,0 +1,85 @@
+package sealion
+
+import (
+	"bytes"
+	"crypto/aes"
+	"testing"
+)
+
+// IEEE 1619-2007, vectors 1 and 15, with AES in place of SEA-Lion
+var xtsAESTests = []struct {
+	key1, key2 string
+	sector     uint64
+	plaintext  string
+	ciphertext string
+}{
+	{
+		"00000000000000000000000000000000", "00000000000000000000000000000000", 0,
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"917cf69ebd68b2ec9b9fe9a3eadda692cd43d2f59598ed858c02c2652fbf922e",
+	},
+	{
+		"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0", "bfbebdbcbbbab9b8b7b6b5b4b3b2b1b0", 0x123456789a,
+		"000102030405060708090a0b0c0d0e0f10",
+		"6c1625db4671522d3d7599601de7ca09ed",
+	},
+}
+
+func TestXTSAES(t *testing.T) {
+	for i, tt := range xtsAESTests {
+		k1, _ := aes.NewCipher(fromHex(tt.key1))
+		k2, _ := aes.NewCipher(fromHex(tt.key2))
+		x := &XTS{k1, k2}
+
+		plaintext, want := fromHex(tt.plaintext), fromHex(tt.ciphertext)
+		ct := make([]byte, len(plaintext))
+		x.EncryptSector(ct, plaintext, tt.sector)
+		if !bytes.Equal(ct, want) {
+			t.Errorf("#%d: got %x, want %x", i, ct, want)
+			continue
+		}
+		pt := make([]byte, len(ct))
+		x.DecryptSector(pt, ct, tt.sector)
+		if !bytes.Equal(pt, plaintext) {
+			t.Errorf("#%d: decrypted %x, want %x", i, pt, plaintext)
+		}
+	}
+}
+
+func TestXTS(t *testing.T) {
+	for _, size := range []int{32, 48, 64} {
+		key := make([]byte, size)
+		key[size/2] = 1
+		x, err := NewXTS(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, n := range []int{16, 17, 31, 32, 33, 512} {
+			plaintext := make([]byte, n)
+			for i := range plaintext {
+				plaintext[i] = byte(i)
+			}
+			buf := append([]byte(nil), plaintext...)
+			x.EncryptSector(buf, buf, 7)
+			if bytes.Equal(buf, plaintext) {
+				t.Errorf("key size %d, length %d: not encrypted", size, n)
+			}
+
+			other := make([]byte, n)
+			x.EncryptSector(other, plaintext, 8)
+			if bytes.Equal(other, buf) {
+				t.Errorf("key size %d, length %d: sector number ignored", size, n)
+			}
+
+			x.DecryptSector(buf, buf, 7)
+			if !bytes.Equal(buf, plaintext) {
+				t.Errorf("key size %d, length %d: round trip failed", size, n)
+			}
+		}
+	}
+
+	if _, err := NewXTS(make([]byte, 16)); err == nil {
+		t.Error("accepted a 16-byte key")
+	}
+}